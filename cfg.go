@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/mlemesle/chip-go-8/lib/cfg"
+	"io"
+	"os"
+)
+
+// runCFG extracts the control-flow graph of a rom and exports it as DOT or JSON
+func runCFG(args []string) error {
+	flags := flag.NewFlagSet("cfg", flag.ExitOnError)
+	format := flags.String("format", "dot", "Output format, dot or json")
+	output := flags.String("o", "", "File to write the graph to. If not set, the graph is printed")
+	symbolFile := flags.String("symbols", "", "File of \"address name\" lines naming the rom's subroutines and labels")
+	platform := addPlatformFlag(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s cfg [flags] rom.ch8\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	positional := parseArgs(flags, args)
+	if len(positional) != 1 {
+		flags.Usage()
+		return errors.New("cfg needs a rom file")
+	}
+	if *format != "dot" && *format != "json" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	table, err := readSymbols(*symbolFile)
+	if err != nil {
+		return err
+	}
+	chip8, _, err := newHeadless(positional[0], *platform, 0)
+	if err != nil {
+		return err
+	}
+	rom := make([]byte, chip8.ROMSize())
+	for i := range rom {
+		rom[i] = chip8.ReadMemory(chip8.LoadAddress() + uint16(i))
+	}
+	graph := cfg.Analyze(rom, chip8.GetPlatform(), chip8.LoadAddress(), chip8.GetState().PC)
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	if *format == "json" {
+		return cfg.WriteJSON(w, graph)
+	}
+	return cfg.WriteDOT(w, graph, table)
+}
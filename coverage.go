@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/mlemesle/chip-go-8/lib/coverage"
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"github.com/mlemesle/chip-go-8/lib/symbols"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// runCoverage runs a rom without display and reports which of its instructions and data were used
+func runCoverage(args []string) error {
+	flags := flag.NewFlagSet("coverage", flag.ExitOnError)
+	frames := flags.Int("frames", 6000, "Number of frames to emulate")
+	ipf := flags.Int("ipf", 0, "Number of instructions emulated per frame. If not set, the rom database's recommendation is used")
+	output := flags.String("o", "", "File to write the report to, as html if it ends with .html. If not set, the report is printed")
+	symbolFile := flags.String("symbols", "", "File of \"address name\" lines labelling the rom's addresses")
+	platform := addPlatformFlag(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s coverage [flags] rom.ch8\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	positional := parseArgs(flags, args)
+	if len(positional) != 1 {
+		flags.Usage()
+		return errors.New("coverage needs a rom file")
+	}
+
+	table, err := readSymbols(*symbolFile)
+	if err != nil {
+		return err
+	}
+	chip8, tickrate, err := newHeadless(positional[0], *platform, *ipf)
+	if err != nil {
+		return err
+	}
+
+	cov := coverage.New()
+	chip8.AddObserver(cov)
+	for frame := 0; frame < *frames; frame++ {
+		if err = chip8.EmulateFrame(tickrate); err != nil {
+			return err
+		}
+	}
+	return writeCoverage(*output, cov, chip8, table)
+}
+
+// writeCoverage writes the coverage report to the given file, or prints it if no file is given
+func writeCoverage(filename string, cov *coverage.Coverage, chip8 *emulator.Chip8, table symbols.Table) error {
+	if filename == "" {
+		return cov.WriteText(os.Stdout, chip8, table)
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if strings.ToLower(filepath.Ext(filename)) == ".html" {
+		return cov.WriteHTML(file, chip8, table)
+	}
+	return cov.WriteText(file, chip8, table)
+}
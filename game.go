@@ -0,0 +1,148 @@
+package main
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"github.com/mlemesle/chip-go-8/lib/beeper"
+	"github.com/mlemesle/chip-go-8/lib/cartridge"
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"github.com/mlemesle/chip-go-8/lib/romdb"
+	"github.com/mlemesle/chip-go-8/lib/romfile"
+	"github.com/mlemesle/chip-go-8/lib/screen"
+	"path/filepath"
+)
+
+// romSettings are the flags applied to every rom the emulator runs, the first one and those chosen in the menu
+type romSettings struct {
+	db       *romdb.Database
+	platform string
+	font     string
+	hybrid   bool
+	ipf      int
+	palette  *screen.Palette
+}
+
+// load loads a rom in the emulator as the flags ask, with the quirks and the platform the rom database recommends
+// Nothing of the rom that ran before is kept: the font, the quirks and the platform are set again.
+func (s romSettings) load(chip8 *emulator.Chip8, romFile string, patches []string) (romdb.Match, bool, error) {
+	chip8.ResetFont()
+	chip8.SetQuirks(emulator.Quirks{})
+	if s.platform == "" {
+		if err := chip8.SetPlatform(emulator.PlatformVIP); err != nil {
+			return romdb.Match{}, false, err
+		}
+	}
+	match, found, err := loadROM(chip8, s.db, romFile, s.platform, patches)
+	if err != nil {
+		return match, found, err
+	}
+	if s.font != "" {
+		if err = loadFont(chip8, s.font); err != nil {
+			return match, found, err
+		}
+	}
+	if s.hybrid {
+		quirks := chip8.GetQuirks()
+		quirks.MachineCode = true
+		chip8.SetQuirks(quirks)
+	}
+	return match, found, nil
+}
+
+// apply applies the keys and the colors the rom database recommends to the screen, and gets the number of
+// instructions to emulate per frame
+// -palette and -ipf override the database.
+func (s romSettings) apply(chip8Screen screen.Chip8ScreenInterface, match romdb.Match, found bool) int {
+	chip8Screen.SetPalette(screen.PaletteClassic)
+	chip8Screen.BindActions(match.Keys)
+	if found {
+		if p, ok := screen.Palettes[match.Palette]; ok {
+			chip8Screen.SetPalette(p)
+		}
+		chip8Screen.SetColors(match.Colors)
+	}
+	if s.palette != nil {
+		chip8Screen.SetPalette(*s.palette)
+	}
+
+	return tickrate(s.ipf, match, found)
+}
+
+// tickrate gets the number of instructions to emulate per frame: ipf if set, or the rom database's recommendation
+func tickrate(ipf int, match romdb.Match, found bool) int {
+	switch {
+	case ipf != 0:
+		return ipf
+	case found:
+		return match.Tickrate
+	}
+	return romdb.DefaultTickrate
+}
+
+// switchROM runs another rom in the emulator, as load does
+// The rom is first loaded in an emulator of its own, the emulator keeps running its rom if the new one cannot be loaded.
+func (s romSettings) switchROM(chip8 *emulator.Chip8, romFile string) (romdb.Match, bool, error) {
+	trial := emulator.New()
+	trial.Initialize(beeper.NewMute())
+	if _, _, err := s.load(trial, romFile, nil); err != nil {
+		return romdb.Match{}, false, err
+	}
+	return s.load(chip8, romFile, nil)
+}
+
+// runRequests resets the rom or runs another one when the controls ask for it, and tells what it did on the OSD
+// It gets the number of instructions to emulate per frame, which may change with the rom, and whether the emulator
+// started a rom again.
+func (s romSettings) runRequests(chip8 *emulator.Chip8, chip8Screen screen.Chip8ScreenInterface, controls *screen.Controls, osd *screen.OSD, ipf int) (int, bool, error) {
+	reset, load := controls.Reset, controls.Load
+	controls.Reset, controls.Load = false, ""
+	defer chip8.SetDraw(true)
+
+	if load != "" {
+		match, found, err := s.switchROM(chip8, load)
+		if err != nil {
+			osd.Message("%v", err)
+			return ipf, false, nil
+		}
+		osd.Message("Loaded %s", filepath.Base(load))
+		return s.apply(chip8Screen, match, found), true, nil
+	}
+	if reset {
+		if err := chip8.Reset(); err != nil {
+			return ipf, false, err
+		}
+		osd.Message("Reset")
+	}
+	return ipf, reset, nil
+}
+
+// describe gets what the rom database knows of a rom file, for the rom browser of the menu
+func (s romSettings) describe(path string) []string {
+	rom, _, err := romfile.Load(path)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if cartridge.IsCartridge(rom) {
+		return []string{"Octo cartridge"}
+	}
+	lines := []string{fmt.Sprintf("%d bytes", len(rom))}
+	match, found, err := s.db.Lookup(fmt.Sprintf("%x", sha1.Sum(rom)))
+	switch {
+	case err != nil:
+		lines = append(lines, err.Error())
+	case found:
+		lines = append(lines, match.Title, "Platform: "+match.Platform, fmt.Sprintf("Tickrate: %d", match.Tickrate))
+	default:
+		lines = append(lines, "Unknown to the rom database")
+	}
+	return lines
+}
+
+// profiles gets the quirks of the platforms of the rom database, for the menu
+func (s romSettings) profiles() []screen.QuirksProfile {
+	var profiles []screen.QuirksProfile
+	for _, p := range s.db.Platforms() {
+		profiles = append(profiles, screen.QuirksProfile{Name: p.Name, Quirks: p.EmulatorQuirks()})
+	}
+	return profiles
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"github.com/mlemesle/chip-go-8/lib/beeper"
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"github.com/mlemesle/chip-go-8/lib/romdb"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSwitchROM(t *testing.T) {
+	dir, err := ioutil.TempDir("", "roms")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	running := filepath.Join(dir, "running.ch8")
+	assert.Nil(t, ioutil.WriteFile(running, []byte{0x60, 0x05, 0x12, 0x02}, 0644))
+	other := filepath.Join(dir, "other.ch8")
+	assert.Nil(t, ioutil.WriteFile(other, []byte{0x61, 0x07, 0x12, 0x02}, 0644))
+	tooBig := filepath.Join(dir, "big.ch8")
+	assert.Nil(t, ioutil.WriteFile(tooBig, make([]byte, 4096), 0644))
+
+	s := romSettings{db: romdb.Embedded()}
+	chip8 := emulator.New()
+	chip8.Initialize(beeper.NewMute())
+	_, _, err = s.load(chip8, running, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, chip8.EmulateFrame(3))
+	before := chip8.GetState()
+	hash := chip8.ROMHash()
+
+	// The running rom goes on untouched when the new one cannot be loaded
+	for _, romFile := range []string{filepath.Join(dir, "missing.ch8"), tooBig} {
+		_, _, err = s.switchROM(chip8, romFile)
+		assert.Error(t, err, romFile)
+		assert.Equal(t, before, chip8.GetState(), romFile)
+		assert.Equal(t, hash, chip8.ROMHash(), romFile)
+		assert.Equal(t, uint8(0x60), chip8.ReadMemory(0x200))
+	}
+
+	_, _, err = s.switchROM(chip8, other)
+	assert.Nil(t, err)
+	assert.Equal(t, uint16(0x200), chip8.GetState().PC)
+	assert.Equal(t, uint8(0x61), chip8.ReadMemory(0x200))
+}
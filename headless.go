@@ -0,0 +1,171 @@
+package main
+
+import (
+	"crypto/sha1"
+	"flag"
+	"fmt"
+	"github.com/mlemesle/chip-go-8/lib/beeper"
+	"github.com/mlemesle/chip-go-8/lib/cartridge"
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"github.com/mlemesle/chip-go-8/lib/patch"
+	"github.com/mlemesle/chip-go-8/lib/romdb"
+	"github.com/mlemesle/chip-go-8/lib/romfile"
+	"github.com/mlemesle/chip-go-8/lib/symbols"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// parseArgs parses the flags of a command, which may come before or after its arguments
+func parseArgs(flags *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		flags.Parse(args)
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// listFlag is a flag that can be repeated, its values are kept in order
+type listFlag []string
+
+// String gets the values of the flag
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+// Set adds a value to the flag
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// addPlatformFlag adds the -platform flag choosing the memory layout of the emulator
+func addPlatformFlag(flags *flag.FlagSet) *string {
+	return flags.String("platform", "", "Memory layout of the machine the rom was written for: "+strings.Join(emulator.PlatformIDs(), ", ")+
+		". If not set, the rom database's platform is used, or vip")
+}
+
+// loadFont sets the font of the emulator, by its ID or from a font file
+func loadFont(chip8 *emulator.Chip8, name string) error {
+	font, ok := emulator.Fonts[name]
+	if !ok {
+		file, err := os.Open(name)
+		if err != nil {
+			return fmt.Errorf("%s is neither a known font (%s) nor a font file: %v", name, strings.Join(emulator.FontIDs(), ", "), err)
+		}
+		defer file.Close()
+		if font, err = emulator.ReadFont(name, file); err != nil {
+			return err
+		}
+	}
+	return chip8.SetFont(font)
+}
+
+// loadROM loads the rom named as with the -rom flag, then applies the quirks and the platform the database recommends
+// A platform given to -platform is used instead of the database's. Octo cartridges bring their own settings.
+// The patches are applied in order before the rom is loaded. The database knows a patched rom by the hash of the rom
+// before the patches, as patches fix or translate roms it knows, or else by its patched hash.
+func loadROM(chip8 *emulator.Chip8, db *romdb.Database, romFile, platformID string, patches []string) (romdb.Match, bool, error) {
+	if platformID != "" {
+		platform, ok := emulator.Platforms[platformID]
+		if !ok {
+			return romdb.Match{}, false, fmt.Errorf("unknown platform %q, known platforms are %s", platformID, strings.Join(emulator.PlatformIDs(), ", "))
+		}
+		if err := chip8.SetPlatform(platform); err != nil {
+			return romdb.Match{}, false, err
+		}
+	}
+	rom, origin, err := romfile.Load(romFile)
+	if err != nil {
+		return romdb.Match{}, false, fmt.Errorf("%s: %v", romFile, err)
+	}
+	if cartridge.IsCartridge(rom) {
+		return loadCartridge(chip8, rom, romFile, patches)
+	}
+	original := rom
+	if rom, err = patch.ApplyFiles(patches, rom); err != nil {
+		return romdb.Match{}, false, err
+	}
+	if err = chip8.LoadAt(rom, origin); err != nil {
+		return romdb.Match{}, false, err
+	}
+
+	match, found, err := db.Lookup(fmt.Sprintf("%x", sha1.Sum(original)))
+	if err == nil && !found && len(patches) > 0 {
+		match, found, err = db.Lookup(chip8.ROMHash())
+	}
+	if err != nil || !found {
+		return match, found, err
+	}
+	chip8.SetQuirks(match.Quirks)
+	if platform, ok := emulator.Platforms[match.PlatformID]; ok && platformID == "" && platform.ID != chip8.GetPlatform().ID &&
+		(origin == romfile.NoOrigin || origin == int(platform.LoadAddress)) {
+		// Changing the platform resets the emulator, the rom is loaded again with the new layout
+		if err = chip8.SetPlatform(platform); err != nil {
+			return match, found, err
+		}
+		err = chip8.LoadBytes(rom)
+	}
+	return match, found, err
+}
+
+// loadCartridge loads the program of an Octo cartridge, and applies its options as the rom database's recommendation
+// The patches apply to the assembled program.
+func loadCartridge(chip8 *emulator.Chip8, data []byte, romFile string, patches []string) (romdb.Match, bool, error) {
+	c, err := cartridge.Decode(data)
+	if err != nil {
+		return romdb.Match{}, false, fmt.Errorf("%s: %v", romFile, err)
+	}
+	rom, err := c.ROM()
+	if err != nil {
+		return romdb.Match{}, false, fmt.Errorf("%s: %v", romFile, err)
+	}
+	if rom, err = patch.ApplyFiles(patches, rom); err != nil {
+		return romdb.Match{}, false, err
+	}
+	if err = chip8.LoadBytes(rom); err != nil {
+		return romdb.Match{}, false, err
+	}
+	if font, ok := c.Options.Font(); ok {
+		if err = chip8.SetFont(font); err != nil {
+			return romdb.Match{}, false, err
+		}
+	}
+	match, err := c.Match(strings.TrimSuffix(filepath.Base(romFile), filepath.Ext(romFile)))
+	if err != nil {
+		return romdb.Match{}, false, fmt.Errorf("%s: %v", romFile, err)
+	}
+	chip8.SetQuirks(match.Quirks)
+	return match, true, nil
+}
+
+// newHeadless creates a muted emulator running the given rom, for tools that do not need a display
+// The rom and its platform are loaded as by the emulator, with the embedded rom database. It also gets the number of
+// instructions to emulate per frame, ipf if set or the database's recommendation, so the rom runs at the emulator's speed.
+func newHeadless(romFile, platform string, ipf int) (*emulator.Chip8, int, error) {
+	chip8 := emulator.New()
+	chip8.Initialize(beeper.NewMute())
+	match, found, err := loadROM(chip8, romdb.Embedded(), romFile, platform, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	return chip8, tickrate(ipf, match, found), nil
+}
+
+// readSymbols reads the symbol file if one is given
+func readSymbols(filename string) (symbols.Table, error) {
+	if filename == "" {
+		return nil, nil
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return symbols.Read(file)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"github.com/mlemesle/chip-go-8/lib/beeper"
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"github.com/mlemesle/chip-go-8/lib/patch"
+	"github.com/mlemesle/chip-go-8/lib/romdb"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePatch writes an IPS patch changing the first byte of the rom
+func writePatch(t *testing.T, dir string, rom []byte) (string, []byte) {
+	patched := append([]byte{0x6A}, rom[1:]...)
+	data, err := patch.Create(patch.IPS, rom, patched)
+	assert.Nil(t, err)
+	filename := filepath.Join(dir, "fix.ips")
+	assert.Nil(t, ioutil.WriteFile(filename, data, 0644))
+	return filename, patched
+}
+
+func TestLoadROM_patched(t *testing.T) {
+	dir, err := ioutil.TempDir("", "patches")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// A patched rom the database knows is recognized by its original hash
+	pong, err := ioutil.ReadFile("rom/pong.c8")
+	assert.Nil(t, err)
+	fix, patched := writePatch(t, dir, pong)
+	chip8 := emulator.New()
+	chip8.Initialize(beeper.NewMute())
+	match, found, err := loadROM(chip8, romdb.Embedded(), "rom/pong.c8", "", []string{fix})
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "Pong", match.Title)
+	assert.Equal(t, fmt.Sprintf("%x", sha1.Sum(patched)), chip8.ROMHash())
+	assert.Equal(t, uint8(0x6A), chip8.ReadMemory(0x200))
+
+	// Otherwise by its patched hash
+	rom := []byte{0x60, 0x01, 0x12, 0x02}
+	romFile := filepath.Join(dir, "game.ch8")
+	assert.Nil(t, ioutil.WriteFile(romFile, rom, 0644))
+	fix, patched = writePatch(t, dir, rom)
+	db := romdb.Embedded()
+	assert.Nil(t, db.Merge(strings.NewReader(fmt.Sprintf(`[{"title": "Game fixed", "roms": {"%x": {"platforms": ["modernChip8"]}}}]`, sha1.Sum(patched)))))
+	match, found, err = loadROM(chip8, db, romFile, "", []string{fix})
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "Game fixed", match.Title)
+
+	// Without patches, only the rom's own hash is looked up
+	_, found, err = loadROM(chip8, db, romFile, "", nil)
+	assert.Nil(t, err)
+	assert.False(t, found)
+}
@@ -0,0 +1,135 @@
+package cartridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"github.com/mlemesle/chip-go-8/lib/octo"
+	"github.com/mlemesle/chip-go-8/lib/romdb"
+	"image/gif"
+)
+
+// fontStyles are the emulator's fonts matching Octo's font styles
+var fontStyles = map[string]string{
+	"octo":      emulator.FontXOCHIP.ID,
+	"vip":       emulator.FontVIP.ID,
+	"dream6800": emulator.FontDREAM6800.ID,
+	"eti660":    emulator.FontETI660.ID,
+	"schip":     emulator.FontSCHIP.ID,
+}
+
+// Options are the settings of Octo a program is shared with
+// Keys binds the actions of the chip-8-database, such as "up" or "a", to CHIP-8 keys.
+type Options struct {
+	Tickrate        int            `json:"tickrate"`
+	FillColor       string         `json:"fillColor"`
+	FillColor2      string         `json:"fillColor2"`
+	BlendColor      string         `json:"blendColor"`
+	BackgroundColor string         `json:"backgroundColor"`
+	ShiftQuirks     bool           `json:"shiftQuirks"`
+	LoadStoreQuirks bool           `json:"loadStoreQuirks"`
+	ClipQuirks      bool           `json:"clipQuirks"`
+	JumpQuirks      bool           `json:"jumpQuirks"`
+	LogicQuirks     bool           `json:"logicQuirks"`
+	VBlankQuirks    bool           `json:"vBlankQuirks"`
+	FontStyle       string         `json:"fontStyle"`
+	Keys            map[string]int `json:"keys"`
+}
+
+// Cartridge is a program exported by Octo (https://github.com/JohnEarnest/Octo) as a GIF image
+type Cartridge struct {
+	Program string  `json:"program"`
+	Options Options `json:"options"`
+}
+
+// IsCartridge tells if data is a GIF image, which may be a cartridge
+func IsCartridge(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("GIF87a")) || bytes.HasPrefix(data, []byte("GIF89a"))
+}
+
+// Decode decodes a cartridge
+// Each byte of the payload is stored in the low nibbles of the palette indexes of 2 pixels, high nibble first,
+// through the frames of the image. The payload is a 32 bits big endian length followed by the cartridge in JSON.
+func Decode(data []byte) (Cartridge, error) {
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return Cartridge{}, err
+	}
+	var payload []byte
+	var high uint8
+	var odd bool
+	for _, frame := range g.Image {
+		b := frame.Bounds()
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				nibble := frame.ColorIndexAt(x, y) & 0xF
+				if odd {
+					payload = append(payload, high<<4|nibble)
+				}
+				high, odd = nibble, !odd
+			}
+		}
+	}
+
+	if len(payload) < 4 {
+		return Cartridge{}, fmt.Errorf("cartridge holds no payload")
+	}
+	size := int(payload[0])<<24 | int(payload[1])<<16 | int(payload[2])<<8 | int(payload[3])
+	if size < 0 || size > len(payload)-4 {
+		return Cartridge{}, fmt.Errorf("cartridge payload of %d bytes is truncated to %d bytes", size, len(payload)-4)
+	}
+	var c Cartridge
+	if err := json.Unmarshal(payload[4:4+size], &c); err != nil {
+		return Cartridge{}, fmt.Errorf("invalid cartridge payload: %v", err)
+	}
+	return c, nil
+}
+
+// ROM assembles the Octo program of the cartridge
+func (c Cartridge) ROM() ([]byte, error) {
+	return octo.Assemble(c.Program)
+}
+
+// Quirks gets the quirks matching Octo's compatibility options
+func (o Options) Quirks() emulator.Quirks {
+	return emulator.Quirks{
+		ShiftVY:    !o.ShiftQuirks,
+		IncrementI: !o.LoadStoreQuirks,
+		Wrap:       !o.ClipQuirks,
+		Jump:       o.JumpQuirks,
+		VBlank:     o.VBlankQuirks,
+		ResetVF:    o.LogicQuirks,
+	}
+}
+
+// Font gets the emulator's font matching Octo's font style, if there is one
+func (o Options) Font() (emulator.Font, bool) {
+	font, ok := emulator.Fonts[fontStyles[o.FontStyle]]
+	return font, ok
+}
+
+// Match gets the settings of the cartridge as the rom database would recommend them
+func (c Cartridge) Match(title string) (romdb.Match, error) {
+	m := romdb.Match{
+		Title:    title,
+		Quirks:   c.Options.Quirks(),
+		Tickrate: c.Options.Tickrate,
+		Keys:     c.Options.Keys,
+	}
+	if m.Tickrate <= 0 {
+		m.Tickrate = romdb.DefaultTickrate
+	}
+	// The colors are in the order of the display's palettes: background, fill, fill 2 and blend
+	for _, s := range []string{c.Options.BackgroundColor, c.Options.FillColor, c.Options.FillColor2, c.Options.BlendColor} {
+		if s == "" {
+			break
+		}
+		rgba, err := romdb.ParseColor(s)
+		if err != nil {
+			return romdb.Match{}, err
+		}
+		m.Colors = append(m.Colors, rgba)
+	}
+	return m, nil
+}
@@ -0,0 +1,65 @@
+package cartridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"testing"
+)
+
+// newCartridge encodes a cartridge as Octo does, in frames of 8x8 pixels
+func newCartridge(t *testing.T, c Cartridge) []byte {
+	data, err := json.Marshal(c)
+	assert.Nil(t, err)
+	size := len(data)
+	payload := append([]byte{byte(size >> 24), byte(size >> 16), byte(size >> 8), byte(size)}, data...)
+
+	g := &gif.GIF{}
+	for len(payload) > 0 {
+		frame := image.NewPaletted(image.Rect(0, 0, 8, 8), palette.Plan9)
+		for i := 0; i < len(frame.Pix)/2 && len(payload) > 0; i++ {
+			// The high nibbles draw the label, only the low nibbles hold data
+			frame.Pix[i*2] = 0x30 | payload[0]>>4
+			frame.Pix[i*2+1] = 0x30 | payload[0]&0xF
+			payload = payload[1:]
+		}
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 0)
+	}
+	var buf bytes.Buffer
+	assert.Nil(t, gif.EncodeAll(&buf, g))
+	return buf.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	data := newCartridge(t, Cartridge{
+		Program: ": main\n  v0 := 5 # the digit\n  i := hex v0\n",
+		Options: Options{Tickrate: 20, BackgroundColor: "#000000", FillColor: "#FFCC00", ClipQuirks: true, FontStyle: "octo"},
+	})
+	assert.True(t, IsCartridge(data))
+
+	c, err := Decode(data)
+	assert.Nil(t, err)
+	rom, err := c.ROM()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x12, 0x02, 0x60, 0x05, 0xF0, 0x29}, rom)
+
+	m, err := c.Match("game")
+	assert.Nil(t, err)
+	assert.Equal(t, 20, m.Tickrate)
+	assert.False(t, m.Quirks.Wrap)
+	assert.True(t, m.Quirks.ShiftVY)
+	assert.Equal(t, []color.RGBA{{0x00, 0x00, 0x00, 0xFF}, {0xFF, 0xCC, 0x00, 0xFF}}, m.Colors)
+	font, ok := c.Options.Font()
+	assert.True(t, ok)
+	assert.Equal(t, "xochip", font.ID)
+}
+
+func TestROM(t *testing.T) {
+	_, err := Cartridge{Program: ": main\n  jump draw\n"}.ROM()
+	assert.EqualError(t, err, `line 2: "draw" is not defined`)
+}
@@ -0,0 +1,98 @@
+package cdp1802
+
+import "fmt"
+
+// MemoryInterface is the memory the CPU runs on
+type MemoryInterface interface {
+	Read(addr uint16) uint8
+	Write(addr uint16, value uint8)
+}
+
+// IOInterface is the devices the CPU talks to with OUT and INP, on ports 1 to 7
+type IOInterface interface {
+	Output(port int, value uint8)
+	Input(port int) uint8
+}
+
+// CPU is an RCA CDP1802 (https://en.wikipedia.org/wiki/RCA_1802), the processor of the COSMAC VIP
+type CPU struct {
+	// R are the 16 scratchpad registers, any of them can be the program counter or the index register
+	R [16]uint16
+	// D is the accumulator
+	D uint8
+	// DF is the carry flag
+	DF uint8
+	// P selects the program counter
+	P uint8
+	// X selects the index register
+	X uint8
+	// T holds X and P while an interrupt is served, or for MARK
+	T uint8
+	// IE enables the interrupts
+	IE bool
+	// Q is the output flip-flop
+	Q bool
+	// EF are the external flags EF1 to EF4, tested by the branches
+	EF [4]bool
+	// Idle is set by IDL, until an interrupt or a DMA request
+	Idle bool
+
+	memory MemoryInterface
+	io     IOInterface
+}
+
+// New creates a CPU as after a reset, running on the given memory
+func New(memory MemoryInterface) *CPU {
+	return &CPU{memory: memory, IE: true}
+}
+
+// SetIO plugs the devices read and written by INP and OUT, with no device the output is lost and the input reads 0
+func (cpu *CPU) SetIO(io IOInterface) {
+	cpu.io = io
+}
+
+// Step fetches and executes one instruction
+func (cpu *CPU) Step() error {
+	opcode := cpu.fetch()
+	// 0x68 is the only opcode the 1802 does not define, later models use it as a prefix
+	if opcode == 0x68 {
+		return fmt.Errorf("unknown 1802 opcode 0x%02X at 0x%04X", opcode, cpu.R[cpu.P]-1)
+	}
+	instructions[opcode>>4](cpu, opcode&0x0F)
+	return nil
+}
+
+// fetch reads the byte at the program counter, then increments it
+func (cpu *CPU) fetch() uint8 {
+	value := cpu.memory.Read(cpu.R[cpu.P])
+	cpu.R[cpu.P]++
+	return value
+}
+
+// rx gets the byte pointed to by the index register
+func (cpu *CPU) rx() uint8 {
+	return cpu.memory.Read(cpu.R[cpu.X])
+}
+
+// setLow sets the low byte of a register
+func (cpu *CPU) setLow(r uint8, value uint8) {
+	cpu.R[r] = cpu.R[r]&0xFF00 | uint16(value)
+}
+
+// setHigh sets the high byte of a register
+func (cpu *CPU) setHigh(r uint8, value uint8) {
+	cpu.R[r] = cpu.R[r]&0x00FF | uint16(value)<<8
+}
+
+// add sets D to a + b + carry, and DF to the carry out
+func (cpu *CPU) add(a, b, carry uint8) {
+	sum := uint16(a) + uint16(b) + uint16(carry)
+	cpu.D = uint8(sum)
+	cpu.DF = uint8(sum >> 8)
+}
+
+// subtract sets D to a - b - borrow, and DF to 1 when there is no borrow out
+func (cpu *CPU) subtract(a, b, borrow uint8) {
+	// a - b - borrow is a + ^b + (1 - borrow)
+	cpu.add(a, ^b, 1-borrow)
+}
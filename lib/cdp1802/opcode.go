@@ -0,0 +1,259 @@
+package cdp1802
+
+// instructions are the 1802's instructions, by the high nibble of their opcode
+// The low nibble is a register or selects a variant of the instruction.
+var instructions = [16]func(cpu *CPU, n uint8){
+	opcode0N, opcode1N, opcode2N, opcode3N,
+	opcode4N, opcode5N, opcode6N, opcode7N,
+	opcode8N, opcode9N, opcodeAN, opcodeBN,
+	opcodeCN, opcodeDN, opcodeEN, opcodeFN,
+}
+
+// condition tests the flag a branch or a skip selects with the low 2 bits of n: always, Q, D = 0 or DF
+// Short branches also test EF1 to EF4 with bit 2.
+func (cpu *CPU) condition(n uint8) bool {
+	if n&0x4 != 0 {
+		return cpu.EF[n&0x3]
+	}
+	switch n & 0x3 {
+	case 1:
+		return cpu.Q
+	case 2:
+		return cpu.D == 0
+	case 3:
+		return cpu.DF == 1
+	}
+	return true
+}
+
+// IDL, LDN RN
+// 00: wait for an interrupt or a DMA request.
+// 0N: D = M(RN), for N from 1 to F.
+func opcode0N(cpu *CPU, n uint8) {
+	if n == 0 {
+		cpu.Idle = true
+		return
+	}
+	cpu.D = cpu.memory.Read(cpu.R[n])
+}
+
+// INC RN
+// RN = RN + 1.
+func opcode1N(cpu *CPU, n uint8) {
+	cpu.R[n]++
+}
+
+// DEC RN
+// RN = RN - 1.
+func opcode2N(cpu *CPU, n uint8) {
+	cpu.R[n]--
+}
+
+// BR, BQ, BZ, BDF, B1-B4, SKP, BNQ, BNZ, BNF, BN1-BN4
+// Short branch: if the condition holds, the low byte of the program counter is set to the next byte,
+// otherwise the next byte is skipped. Bit 3 of N negates the condition, making 38 a skip.
+func opcode3N(cpu *CPU, n uint8) {
+	taken := cpu.condition(n & 0x7)
+	if n&0x8 != 0 {
+		taken = !taken
+	}
+	if taken {
+		cpu.setLow(cpu.P, cpu.memory.Read(cpu.R[cpu.P]))
+		return
+	}
+	cpu.R[cpu.P]++
+}
+
+// LDA RN
+// D = M(RN), then RN = RN + 1.
+func opcode4N(cpu *CPU, n uint8) {
+	cpu.D = cpu.memory.Read(cpu.R[n])
+	cpu.R[n]++
+}
+
+// STR RN
+// M(RN) = D.
+func opcode5N(cpu *CPU, n uint8) {
+	cpu.memory.Write(cpu.R[n], cpu.D)
+}
+
+// IRX, OUT 1-7, INP 1-7
+// 60: RX = RX + 1.
+// 61-67: M(RX) is written to port N, then RX = RX + 1.
+// 69-6F: the byte read from port N - 8 is stored in M(RX) and D.
+func opcode6N(cpu *CPU, n uint8) {
+	switch {
+	case n == 0:
+		cpu.R[cpu.X]++
+	case n < 8:
+		if cpu.io != nil {
+			cpu.io.Output(int(n), cpu.rx())
+		}
+		cpu.R[cpu.X]++
+	default:
+		var value uint8
+		if cpu.io != nil {
+			value = cpu.io.Input(int(n - 8))
+		}
+		cpu.memory.Write(cpu.R[cpu.X], value)
+		cpu.D = value
+	}
+}
+
+// RET, DIS, LDXA, STXD, ADC, SDB, SHRC, SMB, SAV, MARK, REQ, SEQ, ADCI, SDBI, SHLC, SMBI
+// The control and arithmetic instructions with carry.
+func opcode7N(cpu *CPU, n uint8) {
+	switch n {
+	case 0x0, 0x1:
+		// RET and DIS restore X and P from M(RX), then enable or disable interrupts
+		value := cpu.rx()
+		cpu.R[cpu.X]++
+		cpu.X, cpu.P = value>>4, value&0x0F
+		cpu.IE = n == 0x0
+	case 0x2:
+		cpu.D = cpu.rx()
+		cpu.R[cpu.X]++
+	case 0x3:
+		cpu.memory.Write(cpu.R[cpu.X], cpu.D)
+		cpu.R[cpu.X]--
+	case 0x4:
+		cpu.add(cpu.rx(), cpu.D, cpu.DF)
+	case 0x5:
+		cpu.subtract(cpu.rx(), cpu.D, 1-cpu.DF)
+	case 0x6:
+		carry := cpu.DF
+		cpu.DF = cpu.D & 0x1
+		cpu.D = cpu.D>>1 | carry<<7
+	case 0x7:
+		cpu.subtract(cpu.D, cpu.rx(), 1-cpu.DF)
+	case 0x8:
+		cpu.memory.Write(cpu.R[cpu.X], cpu.T)
+	case 0x9:
+		// MARK saves X and P in T and on the stack at R2, for a subroutine to return with RET
+		cpu.T = cpu.X<<4 | cpu.P
+		cpu.memory.Write(cpu.R[2], cpu.T)
+		cpu.X = cpu.P
+		cpu.R[2]--
+	case 0xA:
+		cpu.Q = false
+	case 0xB:
+		cpu.Q = true
+	case 0xC:
+		cpu.add(cpu.fetch(), cpu.D, cpu.DF)
+	case 0xD:
+		cpu.subtract(cpu.fetch(), cpu.D, 1-cpu.DF)
+	case 0xE:
+		carry := cpu.DF
+		cpu.DF = cpu.D >> 7
+		cpu.D = cpu.D<<1 | carry
+	case 0xF:
+		cpu.subtract(cpu.D, cpu.fetch(), 1-cpu.DF)
+	}
+}
+
+// GLO RN
+// D = RN.0, the low byte of RN.
+func opcode8N(cpu *CPU, n uint8) {
+	cpu.D = uint8(cpu.R[n])
+}
+
+// GHI RN
+// D = RN.1, the high byte of RN.
+func opcode9N(cpu *CPU, n uint8) {
+	cpu.D = uint8(cpu.R[n] >> 8)
+}
+
+// PLO RN
+// RN.0 = D.
+func opcodeAN(cpu *CPU, n uint8) {
+	cpu.setLow(n, cpu.D)
+}
+
+// PHI RN
+// RN.1 = D.
+func opcodeBN(cpu *CPU, n uint8) {
+	cpu.setHigh(n, cpu.D)
+}
+
+// LBR, LBQ, LBZ, LBDF, NOP, LSNQ, LSNZ, LSNF, LSKP, LBNQ, LBNZ, LBNF, LSIE, LSQ, LSZ, LSDF
+// Long branch: if the condition holds, the program counter is set to the next 2 bytes, otherwise they are skipped.
+// Long skip: if the condition holds, the next 2 bytes are skipped.
+func opcodeCN(cpu *CPU, n uint8) {
+	var branch, taken bool
+	switch {
+	case n < 0x4:
+		branch, taken = true, cpu.condition(n)
+	case n == 0x4:
+		return
+	case n < 0x8:
+		taken = !cpu.condition(n & 0x3)
+	case n == 0x8:
+		taken = true
+	case n < 0xC:
+		branch, taken = true, !cpu.condition(n&0x3)
+	case n == 0xC:
+		taken = cpu.IE
+	default:
+		taken = cpu.condition(n & 0x3)
+	}
+
+	if branch && taken {
+		pc := cpu.R[cpu.P]
+		cpu.R[cpu.P] = uint16(cpu.memory.Read(pc))<<8 | uint16(cpu.memory.Read(pc+1))
+		return
+	}
+	if branch || taken {
+		cpu.R[cpu.P] += 2
+	}
+}
+
+// SEP RN
+// P = N, RN becomes the program counter.
+func opcodeDN(cpu *CPU, n uint8) {
+	cpu.P = n
+}
+
+// SEX RN
+// X = N, RN becomes the index register.
+func opcodeEN(cpu *CPU, n uint8) {
+	cpu.X = n
+}
+
+// LDX, OR, AND, XOR, ADD, SD, SHR, SM, LDI, ORI, ANI, XRI, ADI, SDI, SHL, SMI
+// The ALU instructions, on M(RX) for F0-F7 and on the immediate next byte for F8-FF.
+func opcodeFN(cpu *CPU, n uint8) {
+	var operand uint8
+	switch {
+	case n&0x7 == 0x6:
+		// SHR and SHL have no operand
+	case n < 0x8:
+		operand = cpu.rx()
+	default:
+		operand = cpu.fetch()
+	}
+
+	switch n & 0x7 {
+	case 0x0:
+		cpu.D = operand
+	case 0x1:
+		cpu.D |= operand
+	case 0x2:
+		cpu.D &= operand
+	case 0x3:
+		cpu.D ^= operand
+	case 0x4:
+		cpu.add(operand, cpu.D, 0)
+	case 0x5:
+		cpu.subtract(operand, cpu.D, 0)
+	case 0x6:
+		if n == 0x6 {
+			cpu.DF = cpu.D & 0x1
+			cpu.D >>= 1
+		} else {
+			cpu.DF = cpu.D >> 7
+			cpu.D <<= 1
+		}
+	case 0x7:
+		cpu.subtract(cpu.D, operand, 0)
+	}
+}
@@ -0,0 +1,402 @@
+package cdp1802
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// ram is a 64K memory for the tests
+type ram [0x10000]uint8
+
+func (m *ram) Read(addr uint16) uint8 {
+	return m[addr]
+}
+
+func (m *ram) Write(addr uint16, value uint8) {
+	m[addr] = value
+}
+
+// ports records the bytes written to the ports and answers reads with the port's number
+type ports struct {
+	out map[int]uint8
+}
+
+func (p *ports) Output(port int, value uint8) {
+	p.out[port] = value
+}
+
+func (p *ports) Input(port int) uint8 {
+	return uint8(port)
+}
+
+// initCPU creates a CPU running the given program from 0x0000 with P = 0 and X = 2
+func initCPU(program ...uint8) (*CPU, *ram) {
+	m := &ram{}
+	copy(m[:], program)
+	cpu := New(m)
+	cpu.X = 2
+	return cpu, m
+}
+
+// step executes one instruction, failing the test on error
+func step(t *testing.T, cpu *CPU) {
+	assert.Nil(t, cpu.Step())
+}
+
+func TestOpcode_00_IDL(t *testing.T) {
+	cpu, _ := initCPU(0x00)
+	step(t, cpu)
+	assert.True(t, cpu.Idle)
+	assert.Equal(t, uint16(1), cpu.R[0])
+}
+
+func TestOpcode_0N_LDN(t *testing.T) {
+	cpu, m := initCPU(0x05)
+	cpu.R[5] = 0x1234
+	m[0x1234] = 0xAB
+	step(t, cpu)
+	assert.Equal(t, uint8(0xAB), cpu.D)
+	assert.Equal(t, uint16(0x1234), cpu.R[5])
+}
+
+func TestOpcode_1N_INC_2N_DEC(t *testing.T) {
+	cpu, _ := initCPU(0x13, 0x24)
+	cpu.R[3] = 0xFFFF
+	step(t, cpu)
+	assert.Equal(t, uint16(0), cpu.R[3])
+	step(t, cpu)
+	assert.Equal(t, uint16(0xFFFF), cpu.R[4])
+}
+
+func TestOpcode_3N_BR(t *testing.T) {
+	cpu, _ := initCPU(0x30, 0x42)
+	step(t, cpu)
+	assert.Equal(t, uint16(0x42), cpu.R[0])
+}
+
+func TestOpcode_3N_BZ(t *testing.T) {
+	cpu, _ := initCPU(0x32, 0x42)
+	cpu.D = 1
+	step(t, cpu)
+	assert.Equal(t, uint16(2), cpu.R[0])
+
+	cpu, _ = initCPU(0x32, 0x42)
+	step(t, cpu)
+	assert.Equal(t, uint16(0x42), cpu.R[0])
+}
+
+func TestOpcode_3N_BN1(t *testing.T) {
+	cpu, _ := initCPU(0x3C, 0x42)
+	cpu.EF[0] = true
+	step(t, cpu)
+	assert.Equal(t, uint16(2), cpu.R[0])
+
+	cpu, _ = initCPU(0x3C, 0x42)
+	step(t, cpu)
+	assert.Equal(t, uint16(0x42), cpu.R[0])
+}
+
+func TestOpcode_38_SKP(t *testing.T) {
+	cpu, _ := initCPU(0x38, 0x42)
+	step(t, cpu)
+	assert.Equal(t, uint16(2), cpu.R[0])
+}
+
+func TestOpcode_4N_LDA(t *testing.T) {
+	cpu, m := initCPU(0x46)
+	cpu.R[6] = 0x100
+	m[0x100] = 0x77
+	step(t, cpu)
+	assert.Equal(t, uint8(0x77), cpu.D)
+	assert.Equal(t, uint16(0x101), cpu.R[6])
+}
+
+func TestOpcode_5N_STR(t *testing.T) {
+	cpu, m := initCPU(0x56)
+	cpu.R[6] = 0x100
+	cpu.D = 0x99
+	step(t, cpu)
+	assert.Equal(t, uint8(0x99), m[0x100])
+}
+
+func TestOpcode_60_IRX(t *testing.T) {
+	cpu, _ := initCPU(0x60)
+	step(t, cpu)
+	assert.Equal(t, uint16(1), cpu.R[2])
+}
+
+func TestOpcode_6N_OUT_INP(t *testing.T) {
+	cpu, m := initCPU(0x63, 0x6B)
+	p := &ports{out: map[int]uint8{}}
+	cpu.SetIO(p)
+	cpu.R[2] = 0x100
+	m[0x100] = 0x55
+	step(t, cpu)
+	assert.Equal(t, uint8(0x55), p.out[3])
+	assert.Equal(t, uint16(0x101), cpu.R[2])
+	step(t, cpu)
+	assert.Equal(t, uint8(3), cpu.D)
+	assert.Equal(t, uint8(3), m[0x101])
+}
+
+func TestOpcode_68_Unknown(t *testing.T) {
+	cpu, _ := initCPU(0x68)
+	assert.NotNil(t, cpu.Step())
+}
+
+func TestOpcode_70_RET(t *testing.T) {
+	cpu, m := initCPU(0x70)
+	cpu.R[2] = 0x100
+	m[0x100] = 0x35
+	cpu.IE = false
+	step(t, cpu)
+	assert.Equal(t, uint8(3), cpu.X)
+	assert.Equal(t, uint8(5), cpu.P)
+	assert.Equal(t, uint16(0x101), cpu.R[2])
+	assert.True(t, cpu.IE)
+}
+
+func TestOpcode_72_LDXA_73_STXD(t *testing.T) {
+	cpu, m := initCPU(0x72, 0x73)
+	cpu.R[2] = 0x100
+	m[0x100] = 0x12
+	step(t, cpu)
+	assert.Equal(t, uint8(0x12), cpu.D)
+	assert.Equal(t, uint16(0x101), cpu.R[2])
+	cpu.D = 0x34
+	step(t, cpu)
+	assert.Equal(t, uint8(0x34), m[0x101])
+	assert.Equal(t, uint16(0x100), cpu.R[2])
+}
+
+func TestOpcode_74_ADC(t *testing.T) {
+	cpu, m := initCPU(0x74)
+	cpu.R[2] = 0x100
+	m[0x100] = 0xF0
+	cpu.D = 0x0F
+	cpu.DF = 1
+	step(t, cpu)
+	assert.Equal(t, uint8(0x00), cpu.D)
+	assert.Equal(t, uint8(1), cpu.DF)
+}
+
+func TestOpcode_75_SDB(t *testing.T) {
+	cpu, m := initCPU(0x75)
+	cpu.R[2] = 0x100
+	m[0x100] = 0x10
+	cpu.D = 0x05
+	cpu.DF = 0
+	step(t, cpu)
+	assert.Equal(t, uint8(0x0A), cpu.D)
+	assert.Equal(t, uint8(1), cpu.DF)
+}
+
+func TestOpcode_76_SHRC_7E_SHLC(t *testing.T) {
+	cpu, _ := initCPU(0x76, 0x7E)
+	cpu.D = 0x81
+	cpu.DF = 0
+	step(t, cpu)
+	assert.Equal(t, uint8(0x40), cpu.D)
+	assert.Equal(t, uint8(1), cpu.DF)
+	step(t, cpu)
+	assert.Equal(t, uint8(0x81), cpu.D)
+	assert.Equal(t, uint8(0), cpu.DF)
+}
+
+func TestOpcode_77_SMB(t *testing.T) {
+	cpu, m := initCPU(0x77)
+	cpu.R[2] = 0x100
+	m[0x100] = 0x10
+	cpu.D = 0x05
+	cpu.DF = 1
+	step(t, cpu)
+	assert.Equal(t, uint8(0xF5), cpu.D)
+	assert.Equal(t, uint8(0), cpu.DF)
+}
+
+func TestOpcode_79_MARK_78_SAV(t *testing.T) {
+	cpu, m := initCPU(0x79, 0x78)
+	cpu.X = 4
+	cpu.R[2] = 0x100
+	step(t, cpu)
+	assert.Equal(t, uint8(0x40), cpu.T)
+	assert.Equal(t, uint8(0x40), m[0x100])
+	assert.Equal(t, uint8(0), cpu.X)
+	assert.Equal(t, uint16(0xFF), cpu.R[2])
+	// X is now P, SAV stores T where the program counter points
+	step(t, cpu)
+	assert.Equal(t, uint8(0x40), m[2])
+}
+
+func TestOpcode_7A_REQ_7B_SEQ(t *testing.T) {
+	cpu, _ := initCPU(0x7B, 0x7A)
+	step(t, cpu)
+	assert.True(t, cpu.Q)
+	step(t, cpu)
+	assert.False(t, cpu.Q)
+}
+
+func TestOpcode_7C_ADCI(t *testing.T) {
+	cpu, _ := initCPU(0x7C, 0x01)
+	cpu.D = 0xFF
+	step(t, cpu)
+	assert.Equal(t, uint8(0x00), cpu.D)
+	assert.Equal(t, uint8(1), cpu.DF)
+	assert.Equal(t, uint16(2), cpu.R[0])
+}
+
+func TestOpcode_8N_GLO_9N_GHI(t *testing.T) {
+	cpu, _ := initCPU(0x87, 0x97)
+	cpu.R[7] = 0xABCD
+	step(t, cpu)
+	assert.Equal(t, uint8(0xCD), cpu.D)
+	step(t, cpu)
+	assert.Equal(t, uint8(0xAB), cpu.D)
+}
+
+func TestOpcode_AN_PLO_BN_PHI(t *testing.T) {
+	cpu, _ := initCPU(0xA7, 0xB7)
+	cpu.D = 0x12
+	step(t, cpu)
+	assert.Equal(t, uint16(0x0012), cpu.R[7])
+	step(t, cpu)
+	assert.Equal(t, uint16(0x1212), cpu.R[7])
+}
+
+func TestOpcode_C0_LBR(t *testing.T) {
+	cpu, _ := initCPU(0xC0, 0x12, 0x34)
+	step(t, cpu)
+	assert.Equal(t, uint16(0x1234), cpu.R[0])
+}
+
+func TestOpcode_CA_LBNZ(t *testing.T) {
+	cpu, _ := initCPU(0xCA, 0x12, 0x34)
+	step(t, cpu)
+	assert.Equal(t, uint16(3), cpu.R[0])
+
+	cpu, _ = initCPU(0xCA, 0x12, 0x34)
+	cpu.D = 1
+	step(t, cpu)
+	assert.Equal(t, uint16(0x1234), cpu.R[0])
+}
+
+func TestOpcode_C4_NOP(t *testing.T) {
+	cpu, _ := initCPU(0xC4)
+	step(t, cpu)
+	assert.Equal(t, uint16(1), cpu.R[0])
+}
+
+func TestOpcode_C8_LSKP(t *testing.T) {
+	cpu, _ := initCPU(0xC8)
+	step(t, cpu)
+	assert.Equal(t, uint16(3), cpu.R[0])
+}
+
+func TestOpcode_CE_LSZ_C6_LSNZ(t *testing.T) {
+	cpu, _ := initCPU(0xCE)
+	step(t, cpu)
+	assert.Equal(t, uint16(3), cpu.R[0])
+
+	cpu, _ = initCPU(0xC6)
+	step(t, cpu)
+	assert.Equal(t, uint16(1), cpu.R[0])
+}
+
+func TestOpcode_CC_LSIE(t *testing.T) {
+	cpu, _ := initCPU(0xCC)
+	step(t, cpu)
+	assert.Equal(t, uint16(3), cpu.R[0])
+
+	cpu, _ = initCPU(0xCC)
+	cpu.IE = false
+	step(t, cpu)
+	assert.Equal(t, uint16(1), cpu.R[0])
+}
+
+func TestOpcode_DN_SEP_EN_SEX(t *testing.T) {
+	cpu, _ := initCPU(0xE5, 0xD3)
+	step(t, cpu)
+	assert.Equal(t, uint8(5), cpu.X)
+	step(t, cpu)
+	assert.Equal(t, uint8(3), cpu.P)
+}
+
+func TestOpcode_F0_LDX(t *testing.T) {
+	cpu, m := initCPU(0xF0)
+	cpu.R[2] = 0x100
+	m[0x100] = 0x42
+	step(t, cpu)
+	assert.Equal(t, uint8(0x42), cpu.D)
+	assert.Equal(t, uint16(0x100), cpu.R[2])
+}
+
+func TestOpcode_F1_OR_F2_AND_F3_XOR(t *testing.T) {
+	cpu, m := initCPU(0xF1, 0xF2, 0xF3)
+	cpu.R[2] = 0x100
+	m[0x100] = 0x0F
+	cpu.D = 0x30
+	step(t, cpu)
+	assert.Equal(t, uint8(0x3F), cpu.D)
+	step(t, cpu)
+	assert.Equal(t, uint8(0x0F), cpu.D)
+	step(t, cpu)
+	assert.Equal(t, uint8(0x00), cpu.D)
+}
+
+func TestOpcode_F4_ADD(t *testing.T) {
+	cpu, m := initCPU(0xF4)
+	cpu.R[2] = 0x100
+	m[0x100] = 0x80
+	cpu.D = 0x81
+	cpu.DF = 1
+	step(t, cpu)
+	assert.Equal(t, uint8(0x01), cpu.D)
+	assert.Equal(t, uint8(1), cpu.DF)
+}
+
+func TestOpcode_F5_SD_F7_SM(t *testing.T) {
+	cpu, m := initCPU(0xF5)
+	cpu.R[2] = 0x100
+	m[0x100] = 0x05
+	cpu.D = 0x10
+	step(t, cpu)
+	assert.Equal(t, uint8(0xF5), cpu.D)
+	assert.Equal(t, uint8(0), cpu.DF)
+
+	cpu, m = initCPU(0xF7)
+	cpu.R[2] = 0x100
+	m[0x100] = 0x05
+	cpu.D = 0x10
+	step(t, cpu)
+	assert.Equal(t, uint8(0x0B), cpu.D)
+	assert.Equal(t, uint8(1), cpu.DF)
+}
+
+func TestOpcode_F6_SHR_FE_SHL(t *testing.T) {
+	cpu, _ := initCPU(0xF6, 0xFE)
+	cpu.D = 0x81
+	step(t, cpu)
+	assert.Equal(t, uint8(0x40), cpu.D)
+	assert.Equal(t, uint8(1), cpu.DF)
+	step(t, cpu)
+	assert.Equal(t, uint8(0x80), cpu.D)
+	assert.Equal(t, uint8(0), cpu.DF)
+}
+
+func TestOpcode_F8_LDI(t *testing.T) {
+	cpu, _ := initCPU(0xF8, 0x42)
+	step(t, cpu)
+	assert.Equal(t, uint8(0x42), cpu.D)
+	assert.Equal(t, uint16(2), cpu.R[0])
+}
+
+func TestOpcode_FD_SDI_FF_SMI(t *testing.T) {
+	cpu, _ := initCPU(0xFD, 0x10, 0xFF, 0x01)
+	cpu.D = 0x05
+	step(t, cpu)
+	assert.Equal(t, uint8(0x0B), cpu.D)
+	assert.Equal(t, uint8(1), cpu.DF)
+	step(t, cpu)
+	assert.Equal(t, uint8(0x0A), cpu.D)
+	assert.Equal(t, uint8(1), cpu.DF)
+}
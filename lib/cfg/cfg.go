@@ -0,0 +1,307 @@
+package cfg
+
+import (
+	"fmt"
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"sort"
+)
+
+// EdgeKind is the way control flows from a block to another
+type EdgeKind string
+
+const (
+	// EdgeNext falls through to the next block
+	EdgeNext EdgeKind = "next"
+	// EdgeJump jumps with 1NNN
+	EdgeJump EdgeKind = "jump"
+	// EdgeCall calls a subroutine with 2NNN
+	EdgeCall EdgeKind = "call"
+	// EdgeReturn continues after a call, once the subroutine returned with 00EE
+	EdgeReturn EdgeKind = "return"
+	// EdgeSkip is taken when a skip instruction skips
+	EdgeSkip EdgeKind = "skip"
+	// EdgeIndirect jumps with BNNN to an address only known at runtime, its target is the base address
+	EdgeIndirect EdgeKind = "indirect"
+)
+
+// Instruction is a decoded instruction of a block
+type Instruction struct {
+	Addr     uint16 `json:"addr"`
+	Opcode   uint16 `json:"opcode"`
+	Mnemonic string `json:"mnemonic"`
+}
+
+// Block is a basic block: instructions executed in sequence, entered only by the first one
+type Block struct {
+	Start        uint16        `json:"start"`
+	End          uint16        `json:"end"`
+	Instructions []Instruction `json:"instructions"`
+	Subroutines  []uint16      `json:"subroutines"`
+}
+
+// Edge is a possible transfer of control between two blocks
+type Edge struct {
+	From uint16   `json:"from"`
+	To   uint16   `json:"to"`
+	Kind EdgeKind `json:"kind"`
+}
+
+// Region is a range of the ROM, End excluded
+type Region struct {
+	Start uint16 `json:"start"`
+	End   uint16 `json:"end"`
+}
+
+// Warning is something suspicious found at an address
+type Warning struct {
+	Addr    uint16 `json:"addr"`
+	Message string `json:"message"`
+}
+
+// Graph is the control-flow graph of a ROM
+type Graph struct {
+	Entry       uint16    `json:"entry"`
+	Blocks      []*Block  `json:"blocks"`
+	Edges       []Edge    `json:"edges"`
+	Subroutines []uint16  `json:"subroutines"`
+	Unreachable []Region  `json:"unreachable"`
+	Warnings    []Warning `json:"warnings"`
+}
+
+// analyzer holds the state of the analysis of a ROM
+type analyzer struct {
+	rom         []byte
+	platform    emulator.Platform
+	origin      uint16
+	reached     map[uint16]bool
+	leaders     map[uint16]bool
+	subroutines map[uint16]bool
+	graph       *Graph
+}
+
+// Analyze builds the control-flow graph of a ROM of the platform loaded at origin, starting at entry
+func Analyze(rom []byte, platform emulator.Platform, origin, entry uint16) *Graph {
+	a := &analyzer{
+		rom:         rom,
+		platform:    platform,
+		origin:      origin,
+		reached:     map[uint16]bool{},
+		leaders:     map[uint16]bool{entry: true},
+		subroutines: map[uint16]bool{entry: true},
+		graph: &Graph{
+			Entry:       entry,
+			Blocks:      []*Block{},
+			Edges:       []Edge{},
+			Subroutines: []uint16{},
+			Unreachable: []Region{},
+			Warnings:    []Warning{},
+		},
+	}
+	a.explore(entry)
+	a.buildBlocks()
+	a.assignSubroutines()
+	a.findUnreachable()
+	a.findDataAsCode()
+	return a.graph
+}
+
+// inROM tells if a whole instruction fits in the ROM at addr
+func (a *analyzer) inROM(addr uint16) bool {
+	return addr >= a.origin && int(addr-a.origin)+1 < len(a.rom)
+}
+
+// opcode gets the opcode at addr
+func (a *analyzer) opcode(addr uint16) uint16 {
+	offset := addr - a.origin
+	return uint16(a.rom[offset])<<8 | uint16(a.rom[offset+1])
+}
+
+// warn records a warning, once per address and message
+func (a *analyzer) warn(addr uint16, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	for _, w := range a.graph.Warnings {
+		if w.Addr == addr && w.Message == message {
+			return
+		}
+	}
+	a.graph.Warnings = append(a.graph.Warnings, Warning{Addr: addr, Message: message})
+}
+
+// successors gets the addresses control can flow to after the instruction at addr
+func (a *analyzer) successors(addr uint16) []uint16 {
+	flow, target := emulator.Decode(a.platform, a.opcode(addr))
+	switch flow {
+	case emulator.FlowJump:
+		return []uint16{target}
+	case emulator.FlowCall:
+		return []uint16{target, addr + 2}
+	case emulator.FlowSkip:
+		return []uint16{addr + 2, addr + 4}
+	case emulator.FlowReturn, emulator.FlowIndirect, emulator.FlowInvalid:
+		return nil
+	}
+	return []uint16{addr + 2}
+}
+
+// explore follows every path from entry, marking reached instructions and block leaders
+func (a *analyzer) explore(entry uint16) {
+	work := []uint16{entry}
+	for len(work) > 0 {
+		addr := work[len(work)-1]
+		work = work[:len(work)-1]
+		if a.reached[addr] {
+			continue
+		}
+		if !a.inROM(addr) {
+			a.warn(addr, "control flows outside of the rom")
+			continue
+		}
+		a.reached[addr] = true
+
+		opcode := a.opcode(addr)
+		flow, target := emulator.Decode(a.platform, opcode)
+		switch flow {
+		case emulator.FlowInvalid:
+			a.warn(addr, "reached data: 0x%04X is not an instruction", opcode)
+		case emulator.FlowIndirect:
+			a.warn(addr, "indirect jump to 0x%03X+V0, its targets are not followed", target)
+		case emulator.FlowCall:
+			a.subroutines[target] = true
+		}
+		successors := a.successors(addr)
+		if flow != emulator.FlowNext {
+			for _, s := range successors {
+				a.leaders[s] = true
+			}
+		}
+		work = append(work, successors...)
+	}
+
+	for addr := range a.reached {
+		if a.reached[addr+1] {
+			a.warn(addr, "instructions at 0x%03X and 0x%03X overlap", addr, addr+1)
+		}
+	}
+}
+
+// buildBlocks splits the reached instructions into basic blocks and links them
+func (a *analyzer) buildBlocks() {
+	addrs := make([]uint16, 0, len(a.reached))
+	for addr := range a.reached {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i] < addrs[j] })
+
+	var block *Block
+	for _, addr := range addrs {
+		if block == nil || a.leaders[addr] || block.End != addr {
+			block = &Block{Start: addr}
+			a.graph.Blocks = append(a.graph.Blocks, block)
+		}
+		opcode := a.opcode(addr)
+		block.Instructions = append(block.Instructions, Instruction{Addr: addr, Opcode: opcode, Mnemonic: emulator.Disassemble(a.platform, opcode)})
+		block.End = addr + 2
+
+		flow, target := emulator.Decode(a.platform, opcode)
+		next := addr + 2
+		switch flow {
+		case emulator.FlowNext:
+			if a.reached[next] && a.leaders[next] {
+				a.link(block.Start, next, EdgeNext)
+			}
+			continue
+		case emulator.FlowJump:
+			a.link(block.Start, target, EdgeJump)
+		case emulator.FlowCall:
+			a.link(block.Start, target, EdgeCall)
+			a.link(block.Start, next, EdgeReturn)
+		case emulator.FlowSkip:
+			a.link(block.Start, next, EdgeNext)
+			a.link(block.Start, next+2, EdgeSkip)
+		case emulator.FlowIndirect:
+			a.link(block.Start, target, EdgeIndirect)
+		}
+		// Any other flow ends the block
+		block = nil
+	}
+}
+
+// link adds an edge, to reached targets only except for indirect jumps
+func (a *analyzer) link(from, to uint16, kind EdgeKind) {
+	if kind != EdgeIndirect && !a.reached[to] {
+		return
+	}
+	a.graph.Edges = append(a.graph.Edges, Edge{From: from, To: to, Kind: kind})
+}
+
+// assignSubroutines finds the blocks of each subroutine, following edges except calls
+func (a *analyzer) assignSubroutines() {
+	blocks := map[uint16]*Block{}
+	for _, b := range a.graph.Blocks {
+		blocks[b.Start] = b
+	}
+	successors := map[uint16][]uint16{}
+	for _, e := range a.graph.Edges {
+		if e.Kind != EdgeCall && e.Kind != EdgeIndirect {
+			successors[e.From] = append(successors[e.From], e.To)
+		}
+	}
+
+	for entry := range a.subroutines {
+		if blocks[entry] != nil {
+			a.graph.Subroutines = append(a.graph.Subroutines, entry)
+		}
+	}
+	sort.Slice(a.graph.Subroutines, func(i, j int) bool { return a.graph.Subroutines[i] < a.graph.Subroutines[j] })
+
+	for _, entry := range a.graph.Subroutines {
+		visited := map[uint16]bool{}
+		work := []uint16{entry}
+		for len(work) > 0 {
+			start := work[len(work)-1]
+			work = work[:len(work)-1]
+			if visited[start] || blocks[start] == nil {
+				continue
+			}
+			visited[start] = true
+			blocks[start].Subroutines = append(blocks[start].Subroutines, entry)
+			work = append(work, successors[start]...)
+		}
+	}
+}
+
+// findUnreachable lists the ranges of the ROM no path reaches
+func (a *analyzer) findUnreachable() {
+	covered := make([]bool, len(a.rom))
+	for addr := range a.reached {
+		covered[addr-a.origin] = true
+		covered[addr-a.origin+1] = true
+	}
+	for offset := 0; offset < len(covered); {
+		if covered[offset] {
+			offset++
+			continue
+		}
+		start := offset
+		for offset < len(covered) && !covered[offset] {
+			offset++
+		}
+		a.graph.Unreachable = append(a.graph.Unreachable, Region{Start: a.origin + uint16(start), End: a.origin + uint16(offset)})
+	}
+}
+
+// findDataAsCode warns about reached instructions that I is set to, as they are likely read as data
+func (a *analyzer) findDataAsCode() {
+	for _, b := range a.graph.Blocks {
+		for _, instruction := range b.Instructions {
+			if instruction.Opcode&0xF000 != 0xA000 {
+				continue
+			}
+			target := instruction.Opcode & 0x0FFF
+			if a.reached[target] || a.reached[target-1] {
+				a.warn(instruction.Addr, "I is set to 0x%03X, which is reached as code: possible data executed as code", target)
+			}
+		}
+	}
+	sort.Slice(a.graph.Warnings, func(i, j int) bool { return a.graph.Warnings[i].Addr < a.graph.Warnings[j].Addr })
+}
@@ -0,0 +1,71 @@
+package cfg
+
+import (
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	rom := []byte{
+		0x22, 0x08, // 200: CALL 0x208
+		0x30, 0x00, // 202: SE V0, 0x00
+		0x12, 0x02, // 204: JP 0x202
+		0x12, 0x06, // 206: JP 0x206
+		0x00, 0xEE, // 208: RET
+		0xF0, 0x90, // 20A: data
+	}
+	g := Analyze(rom, emulator.PlatformVIP, 0x200, 0x200)
+
+	starts := []uint16{}
+	for _, b := range g.Blocks {
+		starts = append(starts, b.Start)
+	}
+	assert.Equal(t, []uint16{0x200, 0x202, 0x204, 0x206, 0x208}, starts)
+	assert.Equal(t, []Edge{
+		{From: 0x200, To: 0x208, Kind: EdgeCall},
+		{From: 0x200, To: 0x202, Kind: EdgeReturn},
+		{From: 0x202, To: 0x204, Kind: EdgeNext},
+		{From: 0x202, To: 0x206, Kind: EdgeSkip},
+		{From: 0x204, To: 0x202, Kind: EdgeJump},
+		{From: 0x206, To: 0x206, Kind: EdgeJump},
+	}, g.Edges)
+	assert.Equal(t, []uint16{0x200, 0x208}, g.Subroutines)
+	assert.Equal(t, []uint16{0x208}, g.Blocks[4].Subroutines)
+	assert.Equal(t, []Region{{Start: 0x20A, End: 0x20C}}, g.Unreachable)
+	assert.Empty(t, g.Warnings)
+}
+
+func TestAnalyze_warnings(t *testing.T) {
+	rom := []byte{
+		0xA2, 0x04, // 200: LD I, 0x204
+		0xB2, 0x08, // 202: JP V0, 0x208
+		0xFF, 0xFF, // 204: reached as data through I only
+	}
+	g := Analyze(rom, emulator.PlatformVIP, 0x200, 0x200)
+	assert.Equal(t, []Warning{
+		{Addr: 0x202, Message: "indirect jump to 0x208+V0, its targets are not followed"},
+	}, g.Warnings)
+	assert.Equal(t, []Edge{{From: 0x200, To: 0x208, Kind: EdgeIndirect}}, g.Edges)
+
+	g = Analyze([]byte{0x60, 0x00, 0xFF, 0xFF}, emulator.PlatformVIP, 0x200, 0x200)
+	assert.Equal(t, []Warning{{Addr: 0x202, Message: "reached data: 0xFFFF is not an instruction"}}, g.Warnings)
+}
+
+func TestAnalyze_chip8X(t *testing.T) {
+	rom := []byte{
+		0xB1, 0x23, // 200: COL V1, V2, 3, not an indirect jump on CHIP-8X
+		0xE0, 0xF2, // 202: SKP2 V0
+		0x12, 0x04, // 204: JP 0x204
+		0x12, 0x06, // 206: JP 0x206
+	}
+	g := Analyze(rom, emulator.PlatformChip8X, 0x200, 0x200)
+	assert.Equal(t, []Edge{
+		{From: 0x200, To: 0x204, Kind: EdgeNext},
+		{From: 0x200, To: 0x206, Kind: EdgeSkip},
+		{From: 0x204, To: 0x204, Kind: EdgeJump},
+		{From: 0x206, To: 0x206, Kind: EdgeJump},
+	}, g.Edges)
+	assert.Equal(t, "COL V1, V2, 3", g.Blocks[0].Instructions[0].Mnemonic)
+	assert.Empty(t, g.Warnings)
+}
@@ -0,0 +1,100 @@
+package cfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/mlemesle/chip-go-8/lib/symbols"
+	"io"
+	"strings"
+)
+
+// edgeStyles are the graphviz attributes of each kind of edge
+var edgeStyles = map[EdgeKind]string{
+	EdgeNext:     "",
+	EdgeJump:     `color="blue"`,
+	EdgeCall:     `style="dashed" label="call"`,
+	EdgeReturn:   `style="dotted" label="return"`,
+	EdgeSkip:     `color="darkorange" label="skip"`,
+	EdgeIndirect: `color="red" style="dashed" label="V0 +"`,
+}
+
+// WriteDOT writes the graph in graphviz's DOT language, a cluster per subroutine
+func WriteDOT(w io.Writer, g *Graph, table symbols.Table) error {
+	var sb strings.Builder
+	sb.WriteString("digraph rom {\n\tnode [shape=box fontname=\"monospace\"];\n")
+
+	warned := map[uint16][]string{}
+	for _, warning := range g.Warnings {
+		warned[warning.Addr] = append(warned[warning.Addr], warning.Message)
+	}
+
+	// A block shared by several subroutines is drawn in the first one
+	clusters := map[uint16][]*Block{}
+	for _, b := range g.Blocks {
+		entry := g.Entry
+		if len(b.Subroutines) > 0 {
+			entry = b.Subroutines[0]
+		}
+		clusters[entry] = append(clusters[entry], b)
+	}
+	for _, entry := range g.Subroutines {
+		fmt.Fprintf(&sb, "\tsubgraph cluster_%03X {\n\t\tlabel=%q;\n", entry, subroutineName(g, table, entry))
+		for _, b := range clusters[entry] {
+			var label strings.Builder
+			for _, instruction := range b.Instructions {
+				if name, ok := table[instruction.Addr]; ok {
+					fmt.Fprintf(&label, "%s:\\l", name)
+				}
+				fmt.Fprintf(&label, "%03X  %s\\l", instruction.Addr, instruction.Mnemonic)
+				for _, message := range warned[instruction.Addr] {
+					fmt.Fprintf(&label, "! %s\\l", strings.Replace(message, `"`, `\"`, -1))
+				}
+			}
+			attributes := ""
+			for _, instruction := range b.Instructions {
+				if len(warned[instruction.Addr]) > 0 {
+					attributes = ` style="filled" fillcolor="#ffcccc"`
+				}
+			}
+			fmt.Fprintf(&sb, "\t\tb%03X [label=\"%s\"%s];\n", b.Start, label.String(), attributes)
+		}
+		sb.WriteString("\t}\n")
+	}
+
+	for _, e := range g.Edges {
+		to := fmt.Sprintf("b%03X", e.To)
+		if e.Kind == EdgeIndirect {
+			to = fmt.Sprintf("indirect%03X", e.To)
+			fmt.Fprintf(&sb, "\t%s [shape=diamond label=\"V0 + 0x%03X\"];\n", to, e.To)
+		}
+		fmt.Fprintf(&sb, "\tb%03X -> %s [%s];\n", e.From, to, edgeStyles[e.Kind])
+	}
+
+	if len(g.Unreachable) > 0 {
+		var label strings.Builder
+		label.WriteString("unreachable\\l")
+		for _, r := range g.Unreachable {
+			fmt.Fprintf(&label, "%03X-%03X (%d bytes)\\l", r.Start, r.End-1, r.End-r.Start)
+		}
+		fmt.Fprintf(&sb, "\tunreachable [shape=note label=\"%s\"];\n", label.String())
+	}
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// subroutineName names a subroutine after its symbol, or its address
+func subroutineName(g *Graph, table symbols.Table, entry uint16) string {
+	if entry == g.Entry {
+		return table.Name(entry, "main_")
+	}
+	return table.Name(entry, "sub_")
+}
+
+// WriteJSON writes the graph as indented JSON
+func WriteJSON(w io.Writer, g *Graph) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(g)
+}
@@ -0,0 +1,84 @@
+package coverage
+
+import (
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+)
+
+// Access is the set of ways an address was used by the ROM
+type Access uint8
+
+const (
+	// Executed means the address was part of an executed instruction
+	Executed Access = 1 << iota
+	// Read means the address was read as data through I
+	Read
+	// Written means the address was written through I
+	Written
+	// SkipTaken means the skip instruction at this address skipped at least once
+	SkipTaken
+	// SkipNotTaken means the skip instruction at this address did not skip at least once
+	SkipNotTaken
+)
+
+// Coverage records how each address of the emulator's memory is used while a ROM runs
+type Coverage struct {
+	accesses []Access
+	state    emulator.State
+}
+
+// New creates a new Coverage
+func New() *Coverage {
+	return &Coverage{}
+}
+
+// Access gets how the given address was used
+func (cov *Coverage) Access(addr uint16) Access {
+	if int(addr) >= len(cov.accesses) {
+		return 0
+	}
+	return cov.accesses[addr]
+}
+
+// BeforeCycle records the instruction about to be executed and the memory it accesses through I
+func (cov *Coverage) BeforeCycle(c *emulator.Chip8) {
+	if len(cov.accesses) != c.MemorySize() {
+		cov.accesses = make([]Access, c.MemorySize())
+	}
+	cov.state = c.GetState()
+	opcode := cov.state.Opcode
+	x := uint16(opcode&0x0F00) >> 8
+
+	cov.mark(cov.state.PC, 2, Executed)
+	switch {
+	case opcode&0xF000 == 0xD000:
+		cov.mark(cov.state.I, opcode&0x000F, Read)
+	case opcode&0xF0FF == 0xF065:
+		cov.mark(cov.state.I, x+1, Read)
+	case opcode&0xF0FF == 0xF033:
+		cov.mark(cov.state.I, 3, Written)
+	case opcode&0xF0FF == 0xF055:
+		cov.mark(cov.state.I, x+1, Written)
+	}
+}
+
+// AfterCycle records the outcome of skip instructions
+func (cov *Coverage) AfterCycle(c *emulator.Chip8) {
+	if !emulator.IsSkip(c.GetPlatform(), cov.state.Opcode) {
+		return
+	}
+	switch c.GetState().PC {
+	case cov.state.PC + 4:
+		cov.mark(cov.state.PC, 1, SkipTaken)
+	case cov.state.PC + 2:
+		cov.mark(cov.state.PC, 1, SkipNotTaken)
+	}
+}
+
+// mark adds the access to length addresses starting at addr
+func (cov *Coverage) mark(addr, length uint16, access Access) {
+	for i := uint16(0); i < length; i++ {
+		if int(addr+i) < len(cov.accesses) {
+			cov.accesses[addr+i] |= access
+		}
+	}
+}
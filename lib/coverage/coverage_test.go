@@ -0,0 +1,100 @@
+package coverage
+
+import (
+	"bytes"
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"github.com/mlemesle/chip-go-8/lib/symbols"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// runROM runs a rom for the given number of cycles under a new Coverage
+func runROM(t *testing.T, rom []byte, cycles int) (*Coverage, *emulator.Chip8) {
+	c := emulator.New()
+	c.Initialize(nil)
+	assert.Nil(t, c.LoadBytes(rom))
+	cov := New()
+	c.AddObserver(cov)
+	for i := 0; i < cycles; i++ {
+		assert.Nil(t, c.EmulateCycle())
+	}
+	return cov, c
+}
+
+var testROM = []byte{
+	0x60, 0x05, // 200: LD V0, 0x05
+	0x30, 0x05, // 202: SE V0, 0x05, always skips
+	0x12, 0x0E, // 204: JP 0x20E, skipped
+	0x40, 0x05, // 206: SNE V0, 0x05, never skips
+	0xA2, 0x10, // 208: LD I, 0x210
+	0xF0, 0x65, // 20A: LD V0, [I]
+	0x12, 0x0C, // 20C: JP 0x20C
+	0x00, 0xE0, // 20E: CLS, never reached
+	0xAB, // 210: data
+	0xCD, // 211: never reached
+}
+
+func TestLines(t *testing.T) {
+	cov, c := runROM(t, testROM, 10)
+
+	type expected struct {
+		addr  uint16
+		kind  kind
+		notes []string
+	}
+	var got []expected
+	for _, l := range cov.lines(c) {
+		got = append(got, expected{addr: l.addr, kind: l.kind, notes: l.notes})
+	}
+	assert.Equal(t, []expected{
+		{addr: 0x200, kind: covered},
+		{addr: 0x202, kind: partial, notes: []string{"skip always taken"}},
+		{addr: 0x204, kind: unreached},
+		{addr: 0x206, kind: partial, notes: []string{"skip never taken"}},
+		{addr: 0x208, kind: covered},
+		{addr: 0x20A, kind: covered},
+		{addr: 0x20C, kind: covered},
+		{addr: 0x20E, kind: unreached},
+		{addr: 0x210, kind: data, notes: []string{"read"}},
+		{addr: 0x211, kind: unreached},
+	}, got)
+}
+
+func TestSummarize(t *testing.T) {
+	cov, c := runROM(t, testROM, 10)
+	assert.Equal(t, Summary{Instructions: 8, Executed: 6, SkipsNeverTaken: 1, SkipsAlways: 1, DataBytes: 1}, cov.Summarize(c))
+}
+
+func TestWriteText(t *testing.T) {
+	cov, c := runROM(t, testROM, 10)
+	var out bytes.Buffer
+	assert.Nil(t, cov.WriteText(&out, c, symbols.Table{0x20C: "loop"}))
+
+	lines := strings.Split(out.String(), "\n")
+	assert.Equal(t, "; 6/8 instructions executed (75.0%), 1 skips never taken, 1 skips always taken, 1 data bytes", lines[0])
+	assert.Contains(t, lines, "! 0x206  4005  SNE V0, 0x05         ; skip never taken")
+	assert.Contains(t, lines, "loop:")
+	assert.Contains(t, lines, "D 0x210  AB    DB 0xAB              ; read")
+	assert.Contains(t, lines, "- 0x211  CD    DB 0xCD")
+}
+
+func TestLines_chip8X(t *testing.T) {
+	c := emulator.New()
+	assert.Nil(t, c.SetPlatform(emulator.PlatformChip8X))
+	assert.Nil(t, c.LoadBytes([]byte{
+		0xE0, 0xF5, // 300: SKNP2 V0, always skips with no key pressed
+		0x00, 0xE0, // 302: CLS, skipped
+		0x13, 0x04, // 304: JP 0x304
+	}))
+	cov := New()
+	c.AddObserver(cov)
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, c.EmulateCycle())
+	}
+
+	l := cov.lines(c)[0]
+	assert.Equal(t, "SKNP2 V0", l.text)
+	assert.Equal(t, partial, l.kind)
+	assert.Equal(t, []string{"skip always taken"}, l.notes)
+}
@@ -0,0 +1,194 @@
+package coverage
+
+import (
+	"fmt"
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"github.com/mlemesle/chip-go-8/lib/symbols"
+	"html"
+	"io"
+	"strings"
+)
+
+// kind is how a line of the annotated disassembly was covered
+type kind int
+
+const (
+	covered kind = iota
+	partial
+	data
+	unreached
+)
+
+// line is a line of the annotated disassembly
+type line struct {
+	addr  uint16
+	bytes []uint8
+	text  string
+	kind  kind
+	notes []string
+}
+
+// Summary counts what the ROM's execution covered
+type Summary struct {
+	Instructions    int
+	Executed        int
+	SkipsNeverTaken int
+	SkipsAlways     int
+	DataBytes       int
+}
+
+// lines splits the loaded ROM into instructions and data, following what was executed
+func (cov *Coverage) lines(c *emulator.Chip8) []line {
+	var lines []line
+	start := c.LoadAddress()
+	end := start + uint16(c.ROMSize())
+	for addr := start; addr < end; {
+		access := cov.Access(addr)
+		l := line{addr: addr}
+
+		switch {
+		case access&Executed != 0:
+			l.bytes = []uint8{c.ReadMemory(addr), c.ReadMemory(addr + 1)}
+			opcode := uint16(l.bytes[0])<<8 | uint16(l.bytes[1])
+			l.text = emulator.Disassemble(c.GetPlatform(), opcode)
+			if emulator.IsSkip(c.GetPlatform(), opcode) {
+				if access&SkipTaken == 0 {
+					l.kind = partial
+					l.notes = append(l.notes, "skip never taken")
+				} else if access&SkipNotTaken == 0 {
+					l.kind = partial
+					l.notes = append(l.notes, "skip always taken")
+				}
+			}
+			if (access|cov.Access(addr+1))&(Read|Written) != 0 {
+				l.notes = append(l.notes, "also used as data")
+			}
+		case access&(Read|Written) != 0:
+			l.kind = data
+			l.bytes = []uint8{c.ReadMemory(addr)}
+			l.text = fmt.Sprintf("DB 0x%02X", l.bytes[0])
+			if access&Read != 0 {
+				l.notes = append(l.notes, "read")
+			}
+			if access&Written != 0 {
+				l.notes = append(l.notes, "written")
+			}
+		case addr+1 >= end || cov.Access(addr+1) != 0:
+			l.kind = unreached
+			l.bytes = []uint8{c.ReadMemory(addr)}
+			l.text = fmt.Sprintf("DB 0x%02X", l.bytes[0])
+		default:
+			l.kind = unreached
+			l.bytes = []uint8{c.ReadMemory(addr), c.ReadMemory(addr + 1)}
+			l.text = emulator.Disassemble(c.GetPlatform(), uint16(l.bytes[0])<<8|uint16(l.bytes[1]))
+		}
+
+		lines = append(lines, l)
+		addr += uint16(len(l.bytes))
+	}
+	return lines
+}
+
+// Summarize counts the covered instructions, skips and data of the loaded ROM
+func (cov *Coverage) Summarize(c *emulator.Chip8) Summary {
+	var s Summary
+	for _, l := range cov.lines(c) {
+		switch l.kind {
+		case covered, partial:
+			s.Instructions++
+			s.Executed++
+		case unreached:
+			if len(l.bytes) == 2 {
+				s.Instructions++
+			}
+		case data:
+			s.DataBytes++
+		}
+		for _, note := range l.notes {
+			switch note {
+			case "skip never taken":
+				s.SkipsNeverTaken++
+			case "skip always taken":
+				s.SkipsAlways++
+			}
+		}
+	}
+	return s
+}
+
+// String formats the summary on one line
+func (s Summary) String() string {
+	percent := 0.0
+	if s.Instructions > 0 {
+		percent = float64(s.Executed) * 100 / float64(s.Instructions)
+	}
+	return fmt.Sprintf("%d/%d instructions executed (%.1f%%), %d skips never taken, %d skips always taken, %d data bytes",
+		s.Executed, s.Instructions, percent, s.SkipsNeverTaken, s.SkipsAlways, s.DataBytes)
+}
+
+// markers are the first column of the text report, one per kind
+var markers = map[kind]string{covered: "X", partial: "!", data: "D", unreached: "-"}
+
+// WriteText writes the annotated disassembly of the loaded ROM as plain text
+func (cov *Coverage) WriteText(w io.Writer, c *emulator.Chip8, table symbols.Table) error {
+	if _, err := fmt.Fprintf(w, "; %s\n; X executed, ! partially executed skip, D data, - never reached\n", cov.Summarize(c)); err != nil {
+		return err
+	}
+	for _, l := range cov.lines(c) {
+		if name, ok := table[l.addr]; ok {
+			fmt.Fprintf(w, "%s:\n", name)
+		}
+		text := fmt.Sprintf("%s 0x%03X  %-5s %-20s", markers[l.kind], l.addr, formatBytes(l.bytes), l.text)
+		if len(l.notes) > 0 {
+			text += " ; " + strings.Join(l.notes, ", ")
+		}
+		if _, err := fmt.Fprintln(w, strings.TrimRight(text, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// classes are the css classes of the html report, one per kind
+var classes = map[kind]string{covered: "covered", partial: "partial", data: "data", unreached: "unreached"}
+
+// WriteHTML writes the annotated disassembly of the loaded ROM as an html page
+func (cov *Coverage) WriteHTML(w io.Writer, c *emulator.Chip8, table symbols.Table) error {
+	var sb strings.Builder
+	sb.WriteString(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>chip-go-8 coverage</title>
+<style>
+body { background: #1e1e1e; color: #ccc; font-family: monospace; }
+.covered { color: #8c8; }
+.partial { color: #000; background: #dc6; }
+.data { color: #8ad; }
+.unreached { color: #000; background: #d66; }
+.label { color: #fff; font-weight: bold; }
+.note { color: #999; }
+</style>
+</head>
+<body>
+`)
+	fmt.Fprintf(&sb, "<p>%s</p>\n<pre>\n", html.EscapeString(cov.Summarize(c).String()))
+	for _, l := range cov.lines(c) {
+		if name, ok := table[l.addr]; ok {
+			fmt.Fprintf(&sb, "<span class=\"label\">%s:</span>\n", html.EscapeString(name))
+		}
+		fmt.Fprintf(&sb, "<span class=\"%s\">0x%03X  %-5s %-20s</span>", classes[l.kind], l.addr, formatBytes(l.bytes), html.EscapeString(l.text))
+		if len(l.notes) > 0 {
+			fmt.Fprintf(&sb, " <span class=\"note\">; %s</span>", strings.Join(l.notes, ", "))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("</pre>\n</body>\n</html>\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// formatBytes formats the bytes of a line as hexadecimal
+func formatBytes(bytes []uint8) string {
+	return fmt.Sprintf("%X", bytes)
+}
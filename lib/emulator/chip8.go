@@ -1,56 +1,62 @@
 package emulator
 
 import (
-	"errors"
+	"crypto/sha1"
+	"fmt"
 	"github.com/mlemesle/chip-go-8/lib/beeper"
-	"os"
+	"github.com/mlemesle/chip-go-8/lib/romfile"
+	"io"
+	"io/ioutil"
 )
 
 const (
-	memorySize    = 4096
-	memoryOffset  = 512
 	registersSize = 16
-	gfxSize       = 32 * 64
 	stackSize     = 16
 	keySize       = 16
 	fontSetSize   = 80
+	// hiResSignature is the jump starting the roms of the 64x64 hi-res CHIP-8
+	hiResSignature = 0x1260
 )
 
-// The Chip8's font set
-var chip8FontSet = [fontSetSize]uint16{
-	0xF0, 0x90, 0x90, 0x90, 0xF0, //0
-	0x20, 0x60, 0x20, 0x20, 0x70, //1
-	0xF0, 0x10, 0xF0, 0x80, 0xF0, //2
-	0xF0, 0x10, 0xF0, 0x10, 0xF0, //3
-	0x90, 0x90, 0xF0, 0x10, 0x10, //4
-	0xF0, 0x80, 0xF0, 0x10, 0xF0, //5
-	0xF0, 0x80, 0xF0, 0x90, 0xF0, //6
-	0xF0, 0x10, 0x20, 0x40, 0x40, //7
-	0xF0, 0x90, 0xF0, 0x90, 0xF0, //8
-	0xF0, 0x90, 0xF0, 0x10, 0xF0, //9
-	0xF0, 0x90, 0xF0, 0x90, 0x90, //A
-	0xE0, 0x90, 0xE0, 0x90, 0xE0, //B
-	0xF0, 0x80, 0x80, 0x80, 0xF0, //C
-	0xE0, 0x90, 0x90, 0x90, 0xE0, //D
-	0xF0, 0x80, 0xF0, 0x80, 0xF0, //E
-	0xF0, 0x80, 0xF0, 0x80, 0x80, //F
-}
-
 // Chip8 is the representation of a chip 8 emulator (https://fr.wikipedia.org/wiki/CHIP-8)
 type Chip8 struct {
 	opcode     uint16
-	memory     [memorySize]uint16
+	memory     []uint16
+	writes     []MemoryWrite
 	registers  [registersSize]uint8
 	i          uint16
 	pc         uint16
-	gfx        [gfxSize]uint8
+	gfx        []uint8
 	delayTimer uint8
 	soundTimer uint8
 	beeper     beeper.BeeperInterface
 	stack      [stackSize]uint16
 	sp         byte
 	key        [keySize]byte
+	key2       [keySize]byte
 	draw       bool
+	rom        []byte
+	romSize    int
+	romHash    string
+	observers  []ObserverInterface
+	quirks     Quirks
+	vblankWait bool
+	platform   Platform
+	font       *Font
+	background uint8
+	colorZones []uint8
+	port       PortInterface
+	// recordSprites makes DXYN record the sprites it draws in sprites, for debugging
+	// newSprites tells that the next sprite drawn starts the sprites of a new frame.
+	recordSprites bool
+	sprites       []Sprite
+	newSprites    bool
+}
+
+// ObserverInterface is notified around each cycle emulated by the emulator
+type ObserverInterface interface {
+	BeforeCycle(c *Chip8)
+	AfterCycle(c *Chip8)
 }
 
 // Chip8Interface is the set of method the emulator needs to implement
@@ -58,36 +64,54 @@ type Chip8Interface interface {
 	Initialize()
 	NeedDraw() bool
 	SetDraw(b bool)
-	GetGFX() [gfxSize]uint8
+	GetGFX() []uint8
 	SetRegisterUp(index int)
 	SetRegisterDown(index int)
 	LoadMemory(filename string) error
 	EmulateCycle(filename string) error
 }
 
-// New creates a default and non-initialized emulator
+// New creates a default and non-initialized emulator, with the memory layout of the COSMAC VIP
 func New() *Chip8 {
-	return &Chip8{}
+	return &Chip8{platform: PlatformVIP}
 }
 
 // Initialize sets defaults value to all fields of the emulator
+// Observers, quirks, platform, font, port and the recording of sprites are kept, so a reset emulator keeps its settings.
 func (c *Chip8) Initialize(b beeper.BeeperInterface) {
-	c.opcode = 0
-	c.memory = [memorySize]uint16{}
-	for i := 0; i < fontSetSize; i++ {
-		c.memory[i] = chip8FontSet[i]
+	if c.platform.MemorySize == 0 {
+		c.platform = PlatformVIP
 	}
+	c.opcode = 0
+	c.memory = make([]uint16, c.platform.MemorySize)
+	c.writeFont()
 	c.registers = [registersSize]uint8{}
 	c.i = 0
-	c.pc = 0x200
-	c.gfx = [gfxSize]uint8{}
+	c.pc = c.platform.StartPC
+	c.gfx = make([]uint8, c.platform.Width*c.platform.Height)
 	c.delayTimer = 0
 	c.soundTimer = 0
 	c.beeper = b
 	c.stack = [stackSize]uint16{}
 	c.sp = 0
 	c.key = [keySize]byte{}
+	c.key2 = [keySize]byte{}
 	c.draw = false
+	c.rom = nil
+	c.romSize = 0
+	c.romHash = ""
+	c.vblankWait = false
+	c.sprites = nil
+	c.background = chip8XDefaultBackground
+	c.colorZones = make([]uint8, c.platform.Width/8*c.platform.Height)
+	for i := range c.colorZones {
+		c.colorZones[i] = chip8XDefaultForeground
+	}
+}
+
+// AddObserver registers an observer notified around each emulated cycle
+func (c *Chip8) AddObserver(o ObserverInterface) {
+	c.observers = append(c.observers, o)
 }
 
 // NeedDraw tells if the emulator needs to draw on the display
@@ -100,51 +124,179 @@ func (c *Chip8) SetDraw(b bool) {
 	c.draw = b
 }
 
-// GetGFX gets the gfx of the emulator
-func (c Chip8) GetGFX() [gfxSize]uint8 {
+// GetGFX gets the gfx of the emulator, a pixel per byte row by row
+// The gfx is shared with the emulator and must not be modified.
+func (c Chip8) GetGFX() []uint8 {
 	return c.gfx
 }
 
+// GetResolution gets the width and the height of the gfx, in pixels
+func (c Chip8) GetResolution() (int, int) {
+	return c.platform.Width, c.platform.Height
+}
+
+// State is a snapshot of the emulator's registers
+type State struct {
+	PC         uint16
+	Opcode     uint16
+	I          uint16
+	SP         byte
+	Registers  [registersSize]uint8
+	Stack      [stackSize]uint16
+	DelayTimer uint8
+	SoundTimer uint8
+}
+
+// GetState gets a snapshot of the emulator's registers
+// Opcode is the one about to be executed at PC
+func (c *Chip8) GetState() State {
+	return State{
+		PC:         c.pc,
+		Opcode:     uint16(c.ReadMemory(c.pc))<<8 | uint16(c.ReadMemory(c.pc+1)),
+		I:          c.i,
+		SP:         c.sp,
+		Registers:  c.registers,
+		Stack:      c.stack,
+		DelayTimer: c.delayTimer,
+		SoundTimer: c.soundTimer,
+	}
+}
+
+// LoadAddress gets the address the rom is loaded at
+func (c *Chip8) LoadAddress() uint16 {
+	return c.platform.LoadAddress
+}
+
+// ROMSize gets the size of the loaded rom
+func (c *Chip8) ROMSize() int {
+	return c.romSize
+}
+
+// ROMHash gets the hexadecimal SHA-1 of the loaded rom, as used by the chip-8-database
+func (c *Chip8) ROMHash() string {
+	return c.romHash
+}
+
+// MemorySize gets the number of addressable bytes of the emulator's memory
+func (c *Chip8) MemorySize() int {
+	return len(c.memory)
+}
+
+// ReadMemory gets the byte stored at the given address, addresses out of memory read as 0
+func (c *Chip8) ReadMemory(addr uint16) uint8 {
+	if int(addr) >= len(c.memory) {
+		return 0
+	}
+	return uint8(c.memory[addr])
+}
+
+// MemoryWrite is a byte written to memory by an instruction, Old is the byte the address held before
+type MemoryWrite struct {
+	Addr  uint16
+	Old   uint8
+	Value uint8
+}
+
+// MemoryWrites gets the bytes written to memory by the last cycle emulated, in order
+func (c *Chip8) MemoryWrites() []MemoryWrite {
+	return c.writes
+}
+
+// writeMemory writes a byte of memory during a cycle, recording the write
+func (c *Chip8) writeMemory(addr int, value uint8) {
+	c.writes = append(c.writes, MemoryWrite{Addr: uint16(addr), Old: uint8(c.memory[addr]), Value: value})
+	c.memory[addr] = uint16(value)
+}
+
 // SetKeyUp sets the value to 'up' for the given key index
+// Indexes 0x10 to 0x1F are the keys of CHIP-8X's second keypad.
 func (c *Chip8) SetKeyUp(index int) {
+	if index >= keySize {
+		c.key2[index-keySize] = 0
+		return
+	}
 	c.key[index] = 0
 }
 
 // SetKeyDown sets the value to 'down' for the given key index
+// Indexes 0x10 to 0x1F are the keys of CHIP-8X's second keypad.
 func (c *Chip8) SetKeyDown(index int) {
+	if index >= keySize {
+		c.key2[index-keySize] = 1
+		return
+	}
 	c.key[index] = 1
 }
 
 // LoadMemory load the file in parameter into the emulator's memory
+// Intel HEX files and hex listings are decoded, see romfile.Decode.
 func (c *Chip8) LoadMemory(filename string) error {
-	file, err := os.OpenFile(filename, os.O_RDONLY, 0)
+	rom, origin, err := romfile.Load(filename)
 	if err != nil {
-		return err
+		return fmt.Errorf("%s: %v", filename, err)
 	}
-	defer file.Close()
+	return c.LoadAt(rom, origin)
+}
 
-	stat, err := file.Stat()
+// LoadROM reads a rom until EOF and loads it into the emulator's memory
+func (c *Chip8) LoadROM(r io.Reader) error {
+	// Reading one byte more than what fits tells a rom that is too big without reading it all
+	rom, err := ioutil.ReadAll(io.LimitReader(r, int64(c.maxROMSize()+1)))
 	if err != nil {
 		return err
 	}
-	if stat.Size() > memorySize-0x200 {
-		return errors.New("File is too big for memory")
-	}
+	return c.LoadBytes(rom)
+}
 
-	fileBuffer := make([]byte, stat.Size())
-	if _, err = file.Read(fileBuffer); err != nil {
-		return err
+// LoadBytes loads a rom into the emulator's memory
+// A rom for the COSMAC VIP starting with the hi-res CHIP-8 boot jump switches the emulator to PlatformHiRes.
+func (c *Chip8) LoadBytes(rom []byte) error {
+	if c.platform.ID == PlatformVIP.ID && len(rom) >= 2 && uint16(rom[0])<<8|uint16(rom[1]) == hiResSignature {
+		if err := c.SetPlatform(PlatformHiRes); err != nil {
+			return err
+		}
 	}
-
-	bufferLen := len(fileBuffer)
-	for i := 0; i < bufferLen; i++ {
-		c.memory[i+memoryOffset] = uint16(fileBuffer[i])
+	if len(rom) > c.maxROMSize() {
+		return fmt.Errorf("rom of %d bytes is too big for memory, at most %d bytes can be loaded", len(rom), c.maxROMSize())
 	}
+	for i := range rom {
+		c.memory[i+int(c.platform.LoadAddress)] = uint16(rom[i])
+	}
+	c.rom = append([]byte{}, rom...)
+	c.romSize = len(rom)
+	c.romHash = fmt.Sprintf("%x", sha1.Sum(rom))
 	return nil
 }
 
+// Reset starts the loaded rom again, as if it had just been loaded
+// The settings are kept as by Initialize, the rom is not read again so roms read from the standard input can be reset.
+func (c *Chip8) Reset() error {
+	rom := c.rom
+	c.Initialize(c.beeper)
+	return c.LoadBytes(rom)
+}
+
+// LoadAt loads a rom written for the given origin, which must be the platform's load address
+// Roms with no origin, romfile.NoOrigin, are loaded at the load address as LoadBytes does.
+func (c *Chip8) LoadAt(rom []byte, origin int) error {
+	if origin != romfile.NoOrigin && origin != int(c.platform.LoadAddress) {
+		return fmt.Errorf("rom starts at 0x%03X but platform %s loads roms at 0x%03X", origin, c.platform.ID, c.platform.LoadAddress)
+	}
+	return c.LoadBytes(rom)
+}
+
+// maxROMSize gets the number of bytes between the load address and the end of memory
+func (c *Chip8) maxROMSize() int {
+	return len(c.memory) - int(c.platform.LoadAddress)
+}
+
 // EmulateCycle emulate a cycle of the emulator's processor
 func (c *Chip8) EmulateCycle() error {
+	for _, o := range c.observers {
+		o.BeforeCycle(c)
+	}
+
+	c.writes = c.writes[:0]
 	c.opcode = uint16(c.memory[c.pc]<<8) | uint16(c.memory[c.pc+1])
 
 	err := handleOpcode(c)
@@ -152,6 +304,23 @@ func (c *Chip8) EmulateCycle() error {
 		return err
 	}
 
+	for _, o := range c.observers {
+		o.AfterCycle(c)
+	}
+	return nil
+}
+
+// EmulateFrame emulate a 60Hz frame: the given number of cycles, then the timers tick once
+// With the VBlank quirk, drawing a sprite ends the frame early.
+func (c *Chip8) EmulateFrame(cycles int) error {
+	c.newSprites = true
+	for i := 0; i < cycles && !c.vblankWait; i++ {
+		if err := c.EmulateCycle(); err != nil {
+			return err
+		}
+	}
+	c.vblankWait = false
+
 	if c.delayTimer > 0 {
 		c.delayTimer--
 	}
@@ -161,6 +330,5 @@ func (c *Chip8) EmulateCycle() error {
 		}
 		c.soundTimer--
 	}
-
 	return nil
 }
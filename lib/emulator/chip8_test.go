@@ -0,0 +1,103 @@
+package emulator
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// endlessReader reads bytes forever, counting them
+type endlessReader struct {
+	read int
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	r.read += len(p)
+	return len(p), nil
+}
+
+// failingReader fails after its data is read
+type failingReader struct {
+	data []byte
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, errors.New("read failed")
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestLoadBytes(t *testing.T) {
+	c := initChip8()
+	assert.Nil(t, c.LoadBytes([]byte("abc")))
+	assert.Equal(t, uint16('a'), c.memory[0x200])
+	assert.Equal(t, uint16('c'), c.memory[0x202])
+	assert.Equal(t, 3, c.ROMSize())
+	assert.Equal(t, "a9993e364706816aba3e25717850c26c9cd0d89d", c.ROMHash())
+
+	// The largest rom fills the memory after the load address
+	c = initChip8()
+	assert.Nil(t, c.LoadBytes(make([]byte, 4096-0x200)))
+	assert.Equal(t, 4096-0x200, c.ROMSize())
+	assert.EqualError(t, initChip8().LoadBytes(make([]byte, 4096-0x200+1)), "rom of 3585 bytes is too big for memory, at most 3584 bytes can be loaded")
+}
+
+func TestLoadROM(t *testing.T) {
+	c := initChip8()
+	assert.Nil(t, c.LoadROM(bytes.NewReader([]byte("abc"))))
+	assert.Equal(t, uint16('b'), c.memory[0x201])
+	assert.Equal(t, 3, c.ROMSize())
+	assert.Equal(t, "a9993e364706816aba3e25717850c26c9cd0d89d", c.ROMHash())
+
+	// A rom too big is refused without being read to the end
+	r := &endlessReader{}
+	assert.EqualError(t, initChip8().LoadROM(r), "rom of 3585 bytes is too big for memory, at most 3584 bytes can be loaded")
+	assert.True(t, r.read < 8192)
+
+	assert.EqualError(t, initChip8().LoadROM(&failingReader{data: []byte("abc")}), "read failed")
+}
+
+func TestReset(t *testing.T) {
+	c := initChip8()
+	rom := []byte{
+		0x60, 0x05, // 200: LD V0, 0x05
+		0xF0, 0x15, // 202: LD DT, V0
+		0xF0, 0x18, // 204: LD ST, V0
+		0xA2, 0x10, // 206: LD I, 0x210
+		0xD0, 0x01, // 208: DRW V0, V0, 1
+		0xF0, 0x55, // 20A: LD [I], V0, writing over the sprite
+		0x22, 0x10, // 20C: CALL 0x210
+		0x00, 0x00, // 20E: padding
+		0x80, 0x00, // 210: the sprite
+	}
+	assert.Nil(t, c.LoadBytes(rom))
+	for i := 0; i < 7; i++ {
+		assert.Nil(t, c.EmulateCycle())
+	}
+	state := c.GetState()
+	assert.Equal(t, uint16(0x210), state.PC)
+	assert.Equal(t, byte(1), state.SP)
+	assert.Equal(t, uint8(5), state.DelayTimer)
+	assert.Equal(t, uint16(0x05), c.memory[0x210])
+	assert.Equal(t, 1, count(c.gfx))
+
+	// Everything starts again from the rom as it was loaded
+	assert.Nil(t, c.Reset())
+	state = c.GetState()
+	assert.Equal(t, uint16(0x200), state.PC)
+	assert.Equal(t, uint16(0), state.I)
+	assert.Equal(t, byte(0), state.SP)
+	assert.Equal(t, [registersSize]uint8{}, state.Registers)
+	assert.Equal(t, [stackSize]uint16{}, state.Stack)
+	assert.Equal(t, uint8(0), state.DelayTimer)
+	assert.Equal(t, uint8(0), state.SoundTimer)
+	assert.Equal(t, 0, count(c.gfx))
+	for i, b := range rom {
+		assert.Equal(t, uint16(b), c.memory[0x200+i])
+	}
+	assert.Equal(t, len(rom), c.ROMSize())
+}
@@ -0,0 +1,55 @@
+package emulator
+
+// Colors of the VP-590 color board CHIP-8X starts with
+const (
+	// chip8XDefaultBackground is dark blue
+	chip8XDefaultBackground = 0
+	// chip8XDefaultForeground is red
+	chip8XDefaultForeground = 1
+	// chip8XBackgrounds is the number of background colors 02A0 cycles through
+	chip8XBackgrounds = 4
+)
+
+// PortInterface is the I/O port of the COSMAC VIP, read and written by CHIP-8X's FXFB and FXF8
+type PortInterface interface {
+	// Output writes a byte to the port
+	Output(value uint8)
+	// Input reads a byte from the port, the bool is false while no byte is available
+	Input() (uint8, bool)
+}
+
+// SetPort plugs a device on the I/O port, with no device the output is lost and the input reads 0
+func (c *Chip8) SetPort(p PortInterface) {
+	c.port = p
+}
+
+// GetColorLayer gets the CHIP-8X colors: the index of the background color, and the index of the foreground color
+// of each zone of 8x1 pixels, row by row
+// ok is false when the platform has no colors.
+func (c *Chip8) GetColorLayer() (background uint8, zones []uint8, ok bool) {
+	if c.platform.ID != PlatformChip8X.ID {
+		return 0, nil, false
+	}
+	return c.background, c.colorZones, true
+}
+
+// setColorZones sets the foreground color of the zones covering columns [column, column+width[ of 8 pixels
+// and rows [row, row+height[, clipped to the screen
+func (c *Chip8) setColorZones(column, row, width, height int, color uint8) {
+	columns := c.platform.Width / 8
+	for y := row; y < row+height && y < c.platform.Height; y++ {
+		for x := column; x < column+width && x < columns; x++ {
+			c.colorZones[x+y*columns] = color & 0x7
+		}
+	}
+}
+
+// isChip8X tells if the CHIP-8X instructions are enabled
+func (c *Chip8) isChip8X() bool {
+	return c.platform.isChip8X()
+}
+
+// isChip8X tells if the platform runs the CHIP-8X instructions
+func (p Platform) isChip8X() bool {
+	return p.ID == PlatformChip8X.ID
+}
@@ -0,0 +1,169 @@
+package emulator
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// testPort is an I/O port recording the bytes written to it, and giving the bytes queued in input
+type testPort struct {
+	output []uint8
+	input  []uint8
+}
+
+func (p *testPort) Output(value uint8) {
+	p.output = append(p.output, value)
+}
+
+func (p *testPort) Input() (uint8, bool) {
+	if len(p.input) == 0 {
+		return 0, false
+	}
+	value := p.input[0]
+	p.input = p.input[1:]
+	return value, true
+}
+
+func initChip8X(t *testing.T) *Chip8 {
+	c := New()
+	assert.Nil(t, c.SetPlatform(PlatformChip8X))
+	return c
+}
+
+// runOpcode executes an opcode at the program counter
+func runOpcode(t *testing.T, c *Chip8, opcode uint16) {
+	c.memory[c.pc] = opcode >> 8
+	c.memory[c.pc+1] = opcode & 0xFF
+	assert.Nil(t, c.EmulateCycle())
+}
+
+func TestChip8X_02A0(t *testing.T) {
+	c := initChip8X(t)
+	for _, expected := range []uint8{1, 2, 3, 0} {
+		runOpcode(t, c, 0x02A0)
+		background, _, ok := c.GetColorLayer()
+		assert.True(t, ok)
+		assert.Equal(t, expected, background)
+	}
+	assert.Equal(t, uint16(0x308), c.pc)
+	assert.True(t, c.draw)
+}
+
+func TestChip8X_BXY0(t *testing.T) {
+	c := initChip8X(t)
+	// Columns 2 and 3, rows 4 to 7, to color 5
+	c.registers[0x0] = 0x12
+	c.registers[0x1] = 0x01
+	c.registers[0x2] = 0x05
+	runOpcode(t, c, 0xB020)
+
+	_, zones, _ := c.GetColorLayer()
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 8; x++ {
+			expected := uint8(chip8XDefaultForeground)
+			if x >= 2 && x <= 3 && y >= 4 && y <= 7 {
+				expected = 5
+			}
+			assert.Equal(t, expected, zones[x+y*8], "zone %d, %d", x, y)
+		}
+	}
+	assert.Equal(t, uint16(0x302), c.pc)
+}
+
+func TestChip8X_BXYN(t *testing.T) {
+	c := initChip8X(t)
+	// The column holding pixel 20, rows 3 and 4, to the color of V4
+	c.registers[0x3] = 20
+	c.registers[0x4] = 0x0E
+	c.registers[0x5] = 3
+	runOpcode(t, c, 0xB352)
+
+	_, zones, _ := c.GetColorLayer()
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 8; x++ {
+			expected := uint8(chip8XDefaultForeground)
+			if x == 2 && (y == 3 || y == 4) {
+				expected = 6
+			}
+			assert.Equal(t, expected, zones[x+y*8], "zone %d, %d", x, y)
+		}
+	}
+}
+
+func TestChip8X_5XY1(t *testing.T) {
+	c := initChip8X(t)
+	c.registers[0x0] = 0x9C
+	c.registers[0x1] = 0x87
+	c.registers[0xF] = 0x42
+	runOpcode(t, c, 0x5011)
+	// 9+8 and C+7 both carry, the carries are lost
+	assert.Equal(t, uint8(0x13), c.registers[0x0])
+	assert.Equal(t, uint8(0x42), c.registers[0xF])
+	assert.Equal(t, uint16(0x302), c.pc)
+}
+
+func TestChip8X_EXF2_EXF5(t *testing.T) {
+	c := initChip8X(t)
+	c.registers[0x0] = 0x3
+	c.SetKeyDown(0x13)
+	runOpcode(t, c, 0xE0F2)
+	assert.Equal(t, uint16(0x304), c.pc)
+	runOpcode(t, c, 0xE0F5)
+	assert.Equal(t, uint16(0x306), c.pc)
+
+	// The key of the first keypad with the same value does not count
+	c.SetKeyUp(0x13)
+	c.SetKeyDown(0x3)
+	runOpcode(t, c, 0xE0F2)
+	assert.Equal(t, uint16(0x308), c.pc)
+	runOpcode(t, c, 0xE0F5)
+	assert.Equal(t, uint16(0x30C), c.pc)
+}
+
+func TestChip8X_FXF8_FXFB(t *testing.T) {
+	c := initChip8X(t)
+	port := &testPort{}
+	c.SetPort(port)
+	c.registers[0x2] = 0xAB
+	runOpcode(t, c, 0xF2F8)
+	assert.Equal(t, []uint8{0xAB}, port.output)
+	assert.Equal(t, uint16(0x302), c.pc)
+
+	// The input waits for a byte
+	runOpcode(t, c, 0xF3FB)
+	assert.Equal(t, uint16(0x302), c.pc)
+	port.input = []uint8{0x5A}
+	runOpcode(t, c, 0xF3FB)
+	assert.Equal(t, uint8(0x5A), c.registers[0x3])
+	assert.Equal(t, uint16(0x304), c.pc)
+}
+
+func TestChip8X_disabled(t *testing.T) {
+	// On other platforms, 5XY1 is not an instruction
+	c := initChip8()
+	c.memory[c.pc] = 0x50
+	c.memory[c.pc+1] = 0x11
+	assert.NotNil(t, c.EmulateCycle())
+
+	_, _, ok := c.GetColorLayer()
+	assert.False(t, ok)
+}
+
+func TestChip8X_disassemble(t *testing.T) {
+	for opcode, mnemonic := range map[uint16]string{
+		0x02A0: "BGC", 0x5121: "ADD V1, V2", 0xB120: "COL V1, V2", 0xB123: "COL V1, V2, 3",
+		0xE3F2: "SKP2 V3", 0xE3F5: "SKNP2 V3", 0xF3F8: "OUT V3", 0xF3FB: "IN V3",
+	} {
+		assert.Equal(t, mnemonic, Disassemble(PlatformChip8X, opcode))
+	}
+	flow, _ := Decode(PlatformChip8X, 0xB123)
+	assert.Equal(t, FlowNext, flow)
+	assert.True(t, IsSkip(PlatformChip8X, 0xE3F2))
+
+	// Other platforms don't run them
+	assert.Equal(t, "JP V0, 0x123", Disassemble(PlatformVIP, 0xB123))
+	flow, _ = Decode(PlatformVIP, 0xE3F5)
+	assert.Equal(t, FlowInvalid, flow)
+	assert.False(t, IsSkip(PlatformVIP, 0xE3F2))
+	assert.Equal(t, "DW 0x5121", Disassemble(PlatformVIP, 0x5121))
+}
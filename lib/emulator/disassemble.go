@@ -0,0 +1,167 @@
+package emulator
+
+import "fmt"
+
+// Flow is the way an opcode changes the program counter
+type Flow int
+
+const (
+	// FlowNext continues with the next instruction
+	FlowNext Flow = iota
+	// FlowJump jumps to the opcode's address
+	FlowJump
+	// FlowCall calls the subroutine at the opcode's address
+	FlowCall
+	// FlowReturn returns from a subroutine
+	FlowReturn
+	// FlowSkip continues with the next instruction, or the one after it
+	FlowSkip
+	// FlowIndirect jumps to an address only known at runtime
+	FlowIndirect
+	// FlowInvalid is not an instruction
+	FlowInvalid
+)
+
+// Disassemble gets the mnemonic of an opcode, using the syntax of the opcodes' documentation
+// Unknown opcodes, and those the platform does not run, are disassembled as raw data.
+func Disassemble(p Platform, opcode uint16) string {
+	mnemonic, _ := disassemble(p, opcode)
+	return mnemonic
+}
+
+// Decode gets how an opcode changes the program counter on the platform, and the address it jumps to if any
+func Decode(p Platform, opcode uint16) (Flow, uint16) {
+	nnn := opcode & 0x0FFF
+	switch {
+	case opcode == 0x00EE:
+		return FlowReturn, 0
+	case opcode&0xF000 == 0x1000:
+		return FlowJump, nnn
+	case opcode&0xF000 == 0x2000:
+		return FlowCall, nnn
+	case opcode&0xF000 == 0xB000 && !p.isChip8X():
+		return FlowIndirect, nnn
+	case IsSkip(p, opcode):
+		return FlowSkip, 0
+	}
+	if _, ok := disassemble(p, opcode); !ok {
+		return FlowInvalid, 0
+	}
+	return FlowNext, 0
+}
+
+// disassemble gets the mnemonic of an opcode, and tells if the platform knows the opcode
+func disassemble(p Platform, opcode uint16) (string, bool) {
+	x := (opcode & 0x0F00) >> 8
+	y := (opcode & 0x00F0) >> 4
+	n := opcode & 0x000F
+	nn := opcode & 0x00FF
+	nnn := opcode & 0x0FFF
+
+	switch {
+	case opcode == 0x00E0:
+		return "CLS", true
+	case opcode == 0x00EE:
+		return "RET", true
+	case opcode == 0x02A0 && p.isChip8X():
+		return "BGC", true
+	case opcode&0xF000 == 0x0000:
+		return fmt.Sprintf("SYS 0x%03X", nnn), true
+	case opcode&0xF000 == 0x1000:
+		return fmt.Sprintf("JP 0x%03X", nnn), true
+	case opcode&0xF000 == 0x2000:
+		return fmt.Sprintf("CALL 0x%03X", nnn), true
+	case opcode&0xF000 == 0x3000:
+		return fmt.Sprintf("SE V%X, 0x%02X", x, nn), true
+	case opcode&0xF000 == 0x4000:
+		return fmt.Sprintf("SNE V%X, 0x%02X", x, nn), true
+	case opcode&0xF00F == 0x5000:
+		return fmt.Sprintf("SE V%X, V%X", x, y), true
+	case opcode&0xF00F == 0x5001 && p.isChip8X():
+		return fmt.Sprintf("ADD V%X, V%X", x, y), true
+	case opcode&0xF000 == 0x6000:
+		return fmt.Sprintf("LD V%X, 0x%02X", x, nn), true
+	case opcode&0xF000 == 0x7000:
+		return fmt.Sprintf("ADD V%X, 0x%02X", x, nn), true
+	case opcode&0xF00F == 0x8000:
+		return fmt.Sprintf("LD V%X, V%X", x, y), true
+	case opcode&0xF00F == 0x8001:
+		return fmt.Sprintf("OR V%X, V%X", x, y), true
+	case opcode&0xF00F == 0x8002:
+		return fmt.Sprintf("AND V%X, V%X", x, y), true
+	case opcode&0xF00F == 0x8003:
+		return fmt.Sprintf("XOR V%X, V%X", x, y), true
+	case opcode&0xF00F == 0x8004:
+		return fmt.Sprintf("ADD V%X, V%X", x, y), true
+	case opcode&0xF00F == 0x8005:
+		return fmt.Sprintf("SUB V%X, V%X", x, y), true
+	case opcode&0xF00F == 0x8006:
+		return fmt.Sprintf("SHR V%X {, V%X}", x, y), true
+	case opcode&0xF00F == 0x8007:
+		return fmt.Sprintf("SUBN V%X, V%X", x, y), true
+	case opcode&0xF00F == 0x800E:
+		return fmt.Sprintf("SHL V%X {, V%X}", x, y), true
+	case opcode&0xF00F == 0x9000:
+		return fmt.Sprintf("SNE V%X, V%X", x, y), true
+	case opcode&0xF000 == 0xA000:
+		return fmt.Sprintf("LD I, 0x%03X", nnn), true
+	case opcode&0xF00F == 0xB000 && p.isChip8X():
+		return fmt.Sprintf("COL V%X, V%X", x, y), true
+	case opcode&0xF000 == 0xB000 && p.isChip8X():
+		return fmt.Sprintf("COL V%X, V%X, %d", x, y, n), true
+	case opcode&0xF000 == 0xB000:
+		return fmt.Sprintf("JP V0, 0x%03X", nnn), true
+	case opcode&0xF000 == 0xC000:
+		return fmt.Sprintf("RND V%X, 0x%02X", x, nn), true
+	case opcode&0xF000 == 0xD000:
+		return fmt.Sprintf("DRW V%X, V%X, %d", x, y, n), true
+	case opcode&0xF0FF == 0xE09E:
+		return fmt.Sprintf("SKP V%X", x), true
+	case opcode&0xF0FF == 0xE0A1:
+		return fmt.Sprintf("SKNP V%X", x), true
+	case opcode&0xF0FF == 0xE0F2 && p.isChip8X():
+		return fmt.Sprintf("SKP2 V%X", x), true
+	case opcode&0xF0FF == 0xE0F5 && p.isChip8X():
+		return fmt.Sprintf("SKNP2 V%X", x), true
+	case opcode&0xF0FF == 0xF007:
+		return fmt.Sprintf("LD V%X, DT", x), true
+	case opcode&0xF0FF == 0xF00A:
+		return fmt.Sprintf("LD V%X, K", x), true
+	case opcode&0xF0FF == 0xF015:
+		return fmt.Sprintf("LD DT, V%X", x), true
+	case opcode&0xF0FF == 0xF018:
+		return fmt.Sprintf("LD ST, V%X", x), true
+	case opcode&0xF0FF == 0xF01E:
+		return fmt.Sprintf("ADD I, V%X", x), true
+	case opcode&0xF0FF == 0xF029:
+		return fmt.Sprintf("LD F, V%X", x), true
+	case opcode&0xF0FF == 0xF030:
+		return fmt.Sprintf("LD HF, V%X", x), true
+	case opcode&0xF0FF == 0xF033:
+		return fmt.Sprintf("LD B, V%X", x), true
+	case opcode&0xF0FF == 0xF055:
+		return fmt.Sprintf("LD [I], V%X", x), true
+	case opcode&0xF0FF == 0xF065:
+		return fmt.Sprintf("LD V%X, [I]", x), true
+	case opcode&0xF0FF == 0xF0F8 && p.isChip8X():
+		return fmt.Sprintf("OUT V%X", x), true
+	case opcode&0xF0FF == 0xF0FB && p.isChip8X():
+		return fmt.Sprintf("IN V%X", x), true
+	}
+	return fmt.Sprintf("DW 0x%04X", opcode), false
+}
+
+// IsSkip tells if the opcode conditionally skips the next instruction on the platform
+func IsSkip(p Platform, opcode uint16) bool {
+	switch {
+	case opcode&0xF000 == 0x3000, opcode&0xF000 == 0x4000:
+		return true
+	case opcode&0xF00F == 0x5000, opcode&0xF00F == 0x9000:
+		return true
+	case opcode&0xF0FF == 0xE09E, opcode&0xF0FF == 0xE0A1:
+		return true
+	case opcode&0xF0FF == 0xE0F2, opcode&0xF0FF == 0xE0F5:
+		return p.isChip8X()
+	}
+	return false
+}
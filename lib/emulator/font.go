@@ -0,0 +1,216 @@
+package emulator
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+)
+
+const (
+	// smallGlyphSize is the number of bytes of a glyph of the small font, 4x5 pixels drawn with FX29
+	smallGlyphSize = 5
+	// largeGlyphSize is the number of bytes of a glyph of the large font, 8x10 pixels drawn with FX30
+	largeGlyphSize = 10
+)
+
+// Font holds the hexadecimal digits drawn by programs
+// Large may be empty, hold the digits 0 to 9 as SUPER-CHIP 1.1 does, or 0 to F.
+type Font struct {
+	ID    string
+	Name  string
+	Small [fontSetSize]uint8
+	Large []uint8
+}
+
+// Size gets the number of bytes the font takes in memory, the large font is stored after the small one
+func (f Font) Size() int {
+	return fontSetSize + len(f.Large)
+}
+
+// Fonts of the interpreters CHIP-8 programs were written for
+var (
+	// FontVIP is the font of the COSMAC VIP interpreter
+	FontVIP = Font{ID: "vip", Name: "COSMAC VIP", Small: [fontSetSize]uint8{
+		0xF0, 0x90, 0x90, 0x90, 0xF0, //0
+		0x60, 0x20, 0x20, 0x20, 0x70, //1
+		0xF0, 0x10, 0xF0, 0x80, 0xF0, //2
+		0xF0, 0x10, 0xF0, 0x10, 0xF0, //3
+		0xA0, 0xA0, 0xF0, 0x20, 0x20, //4
+		0xF0, 0x80, 0xF0, 0x10, 0xF0, //5
+		0xF0, 0x80, 0xF0, 0x90, 0xF0, //6
+		0xF0, 0x10, 0x10, 0x10, 0x10, //7
+		0xF0, 0x90, 0xF0, 0x90, 0xF0, //8
+		0xF0, 0x90, 0xF0, 0x10, 0xF0, //9
+		0xF0, 0x90, 0xF0, 0x90, 0x90, //A
+		0xF0, 0x50, 0x70, 0x50, 0xF0, //B
+		0xF0, 0x80, 0x80, 0x80, 0xF0, //C
+		0xF0, 0x50, 0x50, 0x50, 0xF0, //D
+		0xF0, 0x80, 0xF0, 0x80, 0xF0, //E
+		0xF0, 0x80, 0xF0, 0x80, 0x80, //F
+	}}
+	// FontDREAM6800 is the font of CHIPOS, the DREAM 6800's interpreter
+	FontDREAM6800 = Font{ID: "dream6800", Name: "DREAM 6800", Small: [fontSetSize]uint8{
+		0xE0, 0xA0, 0xA0, 0xA0, 0xE0, //0
+		0x40, 0x40, 0x40, 0x40, 0x40, //1
+		0xE0, 0x20, 0xE0, 0x80, 0xE0, //2
+		0xE0, 0x20, 0xE0, 0x20, 0xE0, //3
+		0x80, 0xA0, 0xA0, 0xE0, 0x20, //4
+		0xE0, 0x80, 0xE0, 0x20, 0xE0, //5
+		0xE0, 0x80, 0xE0, 0xA0, 0xE0, //6
+		0xE0, 0x20, 0x20, 0x20, 0x20, //7
+		0xE0, 0xA0, 0xE0, 0xA0, 0xE0, //8
+		0xE0, 0xA0, 0xE0, 0x20, 0xE0, //9
+		0xE0, 0xA0, 0xE0, 0xA0, 0xA0, //A
+		0xC0, 0xA0, 0xE0, 0xA0, 0xC0, //B
+		0xE0, 0x80, 0x80, 0x80, 0xE0, //C
+		0xC0, 0xA0, 0xA0, 0xA0, 0xC0, //D
+		0xE0, 0x80, 0xE0, 0x80, 0xE0, //E
+		0xE0, 0x80, 0xC0, 0x80, 0x80, //F
+	}}
+	// FontETI660 is the font of the ETI-660's interpreter
+	FontETI660 = Font{ID: "eti660", Name: "ETI-660", Small: [fontSetSize]uint8{
+		0xE0, 0xA0, 0xA0, 0xA0, 0xE0, //0
+		0x20, 0x20, 0x20, 0x20, 0x20, //1
+		0xE0, 0x20, 0xE0, 0x80, 0xE0, //2
+		0xE0, 0x20, 0xE0, 0x20, 0xE0, //3
+		0xA0, 0xA0, 0xE0, 0x20, 0x20, //4
+		0xE0, 0x80, 0xE0, 0x20, 0xE0, //5
+		0xE0, 0x80, 0xE0, 0xA0, 0xE0, //6
+		0xE0, 0x20, 0x20, 0x20, 0x20, //7
+		0xE0, 0xA0, 0xE0, 0xA0, 0xE0, //8
+		0xE0, 0xA0, 0xE0, 0x20, 0xE0, //9
+		0xE0, 0xA0, 0xE0, 0xA0, 0xA0, //A
+		0x80, 0x80, 0xE0, 0xA0, 0xE0, //B
+		0xE0, 0x80, 0x80, 0x80, 0xE0, //C
+		0x20, 0x20, 0xE0, 0xA0, 0xE0, //D
+		0xE0, 0x80, 0xE0, 0x80, 0xE0, //E
+		0xE0, 0x80, 0xC0, 0x80, 0x80, //F
+	}}
+	// FontSCHIP is the font of CHIP-48 and SUPER-CHIP 1.1, whose large font only has the digits 0 to 9
+	FontSCHIP = Font{ID: "schip", Name: "SUPER-CHIP", Small: chip48Small, Large: []uint8{
+		0x3C, 0x7E, 0xE7, 0xC3, 0xC3, 0xC3, 0xC3, 0xE7, 0x7E, 0x3C, //0
+		0x18, 0x38, 0x58, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x3C, //1
+		0x3E, 0x7F, 0xC3, 0x06, 0x0C, 0x18, 0x30, 0x60, 0xFF, 0xFF, //2
+		0x3C, 0x7E, 0xC3, 0x03, 0x0E, 0x0E, 0x03, 0xC3, 0x7E, 0x3C, //3
+		0x06, 0x0E, 0x1E, 0x36, 0x66, 0xC6, 0xFF, 0xFF, 0x06, 0x06, //4
+		0xFF, 0xFF, 0xC0, 0xC0, 0xFC, 0xFE, 0x03, 0xC3, 0x7E, 0x3C, //5
+		0x3E, 0x7C, 0xE0, 0xC0, 0xFC, 0xFE, 0xC3, 0xC3, 0x7E, 0x3C, //6
+		0xFF, 0xFF, 0x03, 0x06, 0x0C, 0x18, 0x30, 0x60, 0x60, 0x60, //7
+		0x3C, 0x7E, 0xC3, 0xC3, 0x7E, 0x7E, 0xC3, 0xC3, 0x7E, 0x3C, //8
+		0x3C, 0x7E, 0xC3, 0xC3, 0x7F, 0x3F, 0x03, 0x03, 0x3E, 0x7C, //9
+	}}
+	// FontXOCHIP is the font of Octo's XO-CHIP, whose large font has all the hexadecimal digits
+	FontXOCHIP = Font{ID: "xochip", Name: "XO-CHIP", Small: chip48Small, Large: []uint8{
+		0xFF, 0xFF, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xFF, 0xFF, //0
+		0x18, 0x78, 0x78, 0x18, 0x18, 0x18, 0x18, 0x18, 0xFF, 0xFF, //1
+		0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, //2
+		0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, //3
+		0xC3, 0xC3, 0xC3, 0xC3, 0xFF, 0xFF, 0x03, 0x03, 0x03, 0x03, //4
+		0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, //5
+		0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF, //6
+		0xFF, 0xFF, 0x03, 0x03, 0x06, 0x0C, 0x18, 0x18, 0x18, 0x18, //7
+		0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF, //8
+		0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, //9
+		0x7E, 0xFF, 0xC3, 0xC3, 0xC3, 0xFF, 0xFF, 0xC3, 0xC3, 0xC3, //A
+		0xFC, 0xFC, 0xC3, 0xC3, 0xFC, 0xFC, 0xC3, 0xC3, 0xFC, 0xFC, //B
+		0x3C, 0xFF, 0xC3, 0xC0, 0xC0, 0xC0, 0xC0, 0xC3, 0xFF, 0x3C, //C
+		0xFC, 0xFE, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xFE, 0xFC, //D
+		0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, //E
+		0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC0, 0xC0, 0xC0, 0xC0, //F
+	}}
+)
+
+// chip48Small is the small font of CHIP-48, kept by its successors
+var chip48Small = [fontSetSize]uint8{
+	0xF0, 0x90, 0x90, 0x90, 0xF0, //0
+	0x20, 0x60, 0x20, 0x20, 0x70, //1
+	0xF0, 0x10, 0xF0, 0x80, 0xF0, //2
+	0xF0, 0x10, 0xF0, 0x10, 0xF0, //3
+	0x90, 0x90, 0xF0, 0x10, 0x10, //4
+	0xF0, 0x80, 0xF0, 0x10, 0xF0, //5
+	0xF0, 0x80, 0xF0, 0x90, 0xF0, //6
+	0xF0, 0x10, 0x20, 0x40, 0x40, //7
+	0xF0, 0x90, 0xF0, 0x90, 0xF0, //8
+	0xF0, 0x90, 0xF0, 0x10, 0xF0, //9
+	0xF0, 0x90, 0xF0, 0x90, 0x90, //A
+	0xE0, 0x90, 0xE0, 0x90, 0xE0, //B
+	0xF0, 0x80, 0x80, 0x80, 0xF0, //C
+	0xE0, 0x90, 0x90, 0x90, 0xE0, //D
+	0xF0, 0x80, 0xF0, 0x80, 0xF0, //E
+	0xF0, 0x80, 0xF0, 0x80, 0x80, //F
+}
+
+// Fonts are the fonts known by the emulator, by ID
+var Fonts = map[string]Font{
+	FontVIP.ID:       FontVIP,
+	FontDREAM6800.ID: FontDREAM6800,
+	FontETI660.ID:    FontETI660,
+	FontSCHIP.ID:     FontSCHIP,
+	FontXOCHIP.ID:    FontXOCHIP,
+}
+
+// FontIDs gets the IDs of the known fonts, sorted
+func FontIDs() []string {
+	var ids []string
+	for id := range Fonts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ReadFont reads a font file: the 80 bytes of the small font, optionally followed by the 100 bytes
+// of a large font of the digits 0 to 9, or the 160 bytes of a large font of the digits 0 to F
+func ReadFont(id string, r io.Reader) (Font, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return Font{}, err
+	}
+	large := len(data) - fontSetSize
+	if large != 0 && large != 10*largeGlyphSize && large != 16*largeGlyphSize {
+		return Font{}, fmt.Errorf("font %s: %d bytes, expected %d, %d or %d", id, len(data), fontSetSize, fontSetSize+10*largeGlyphSize, fontSetSize+16*largeGlyphSize)
+	}
+	f := Font{ID: id, Name: id}
+	copy(f.Small[:], data)
+	if large > 0 {
+		f.Large = data[fontSetSize:]
+	}
+	return f, nil
+}
+
+// SetFont sets the font of the emulator instead of its platform's, and writes it in memory
+func (c *Chip8) SetFont(f Font) error {
+	if int(c.platform.FontAddress)+f.Size() > c.platform.MemorySize {
+		return fmt.Errorf("font %s does not fit in memory at 0x%03X", f.ID, c.platform.FontAddress)
+	}
+	c.font = &f
+	c.writeFont()
+	return nil
+}
+
+// ResetFont goes back to the platform's font, undoing SetFont
+func (c *Chip8) ResetFont() {
+	c.font = nil
+	c.writeFont()
+}
+
+// GetFont gets the font of the emulator
+func (c *Chip8) GetFont() Font {
+	if c.font != nil {
+		return *c.font
+	}
+	return Fonts[c.platform.Font]
+}
+
+// writeFont writes the font in memory at the platform's font address, the large font after the small one
+func (c *Chip8) writeFont() {
+	f := c.GetFont()
+	addr := int(c.platform.FontAddress)
+	for i, b := range f.Small {
+		c.memory[addr+i] = uint16(b)
+	}
+	for i, b := range f.Large {
+		c.memory[addr+fontSetSize+i] = uint16(b)
+	}
+}
@@ -0,0 +1,61 @@
+package emulator
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestReadFont(t *testing.T) {
+	for size, large := range map[int]int{80: 0, 180: 100, 240: 160} {
+		data := make([]byte, size)
+		data[0], data[size-1] = 0xF0, 0x3C
+		f, err := ReadFont("custom", bytes.NewReader(data))
+		assert.Nil(t, err, "%d bytes", size)
+		assert.Equal(t, "custom", f.ID)
+		assert.Equal(t, uint8(0xF0), f.Small[0])
+		assert.Equal(t, large, len(f.Large), "%d bytes", size)
+		assert.Equal(t, size, f.Size())
+	}
+
+	for _, size := range []int{0, 79, 81, 160, 181, 256} {
+		_, err := ReadFont("custom", bytes.NewReader(make([]byte, size)))
+		assert.Error(t, err, "%d bytes", size)
+	}
+}
+
+// initChip8Font creates an emulator with its font at 0x050 and the given font loaded
+func initChip8Font(t *testing.T, f Font) *Chip8 {
+	p := PlatformVIP
+	p.ID = "font"
+	p.FontAddress = 0x050
+	c := New()
+	assert.Nil(t, c.SetPlatform(p))
+	assert.Nil(t, c.SetFont(f))
+	return c
+}
+
+func TestFont_FX29(t *testing.T) {
+	c := initChip8Font(t, FontDREAM6800)
+	c.registers[0x3] = 0xB
+	runOpcode(t, c, 0xF329)
+	assert.Equal(t, uint16(0x050+0xB*5), c.i)
+	for i, b := range FontDREAM6800.Small[0xB*5 : 0xC*5] {
+		assert.Equal(t, uint16(b), c.memory[int(c.i)+i])
+	}
+}
+
+func TestFont_FX30(t *testing.T) {
+	c := initChip8Font(t, FontXOCHIP)
+	c.registers[0x3] = 0xB
+	runOpcode(t, c, 0xF330)
+	assert.Equal(t, uint16(0x050+80+0xB*10), c.i)
+	for i, b := range FontXOCHIP.Large[0xB*10 : 0xC*10] {
+		assert.Equal(t, uint16(b), c.memory[int(c.i)+i])
+	}
+
+	// Fonts without a large font have no FX30
+	c = initChip8Font(t, FontVIP)
+	c.memory[c.pc], c.memory[c.pc+1] = 0xF3, 0x30
+	assert.Error(t, c.EmulateCycle())
+}
@@ -0,0 +1,71 @@
+package emulator
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLoadBytes_hiResSignature(t *testing.T) {
+	// The boot jump of hi-res roms switches a VIP to the hi-res platform
+	c := initChip8()
+	assert.Nil(t, c.LoadBytes([]byte{0x12, 0x60, 0x00, 0xE0}))
+	assert.Equal(t, PlatformHiRes.ID, c.GetPlatform().ID)
+	w, h := c.GetResolution()
+	assert.Equal(t, 64, w)
+	assert.Equal(t, 64, h)
+	assert.Equal(t, 64*64, len(c.GetGFX()))
+	assert.Equal(t, uint16(0x2C0), c.pc)
+	assert.Equal(t, uint16(0x12), c.memory[0x200])
+
+	// Other roms stay on the VIP
+	c = initChip8()
+	assert.Nil(t, c.LoadBytes([]byte{0x12, 0x00}))
+	assert.Equal(t, PlatformVIP.ID, c.GetPlatform().ID)
+	w, h = c.GetResolution()
+	assert.Equal(t, 64, w)
+	assert.Equal(t, 32, h)
+	assert.Equal(t, uint16(0x200), c.pc)
+
+	// A platform chosen before loading is kept
+	c = initChip8()
+	assert.Nil(t, c.SetPlatform(PlatformETI660))
+	assert.Nil(t, c.LoadBytes([]byte{0x12, 0x60}))
+	assert.Equal(t, PlatformETI660.ID, c.GetPlatform().ID)
+}
+
+func TestHiRes_0230(t *testing.T) {
+	c := initChip8()
+	assert.Nil(t, c.SetPlatform(PlatformHiRes))
+	for i := range c.gfx {
+		c.gfx[i] = 1
+	}
+	runOpcode(t, c, 0x0230)
+	assert.Equal(t, 0, count(c.gfx))
+	assert.True(t, c.draw)
+	assert.Equal(t, uint16(0x2C2), c.pc)
+
+	// On other platforms, 0230 is a machine-code subroutine the emulator ignores
+	c = initChip8()
+	c.gfx[0] = 1
+	runOpcode(t, c, 0x0230)
+	assert.Equal(t, uint8(1), c.gfx[0])
+}
+
+func TestHiRes_DXYN(t *testing.T) {
+	// Sprites are drawn on the lower half of the 64x64 display
+	c := initChip8()
+	assert.Nil(t, c.SetPlatform(PlatformHiRes))
+	c.registers[0x0] = 2
+	c.registers[0x1] = 40
+	c.i = 0x300
+	c.memory[0x300] = 0x80
+	runOpcode(t, c, 0xD011)
+	assert.Equal(t, uint8(1), c.gfx[40*64+2])
+	assert.Equal(t, 1, count(c.gfx))
+
+	// And start from the top again past the bottom
+	c.registers[0x1] = 64 + 1
+	runOpcode(t, c, 0xD011)
+	assert.Equal(t, uint8(1), c.gfx[1*64+2])
+	assert.Equal(t, 2, count(c.gfx))
+}
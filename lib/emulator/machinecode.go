@@ -0,0 +1,96 @@
+package emulator
+
+import (
+	"fmt"
+	"github.com/mlemesle/chip-go-8/lib/cdp1802"
+)
+
+// The COSMAC VIP interpreter keeps its state in the last 352 bytes of memory, machine-code subroutines
+// find it there
+const (
+	// vipStackOffset is where R2, the 1802's stack pointer, points to from the end of memory
+	vipStackOffset = 0x131
+	// vipRegistersOffset is where V0 to VF are stored from the end of memory
+	vipRegistersOffset = 0x110
+	// vipDisplayOffset is where the 64x32 display is stored from the end of memory, a bit per pixel
+	vipDisplayOffset = 0x100
+	// machineCodeStepLimit is the number of 1802 instructions after which a subroutine is considered stuck
+	machineCodeStepLimit = 1 << 20
+)
+
+// machineMemory gives the 1802 access to the emulator's memory, which wraps around like the VIP's
+type machineMemory struct {
+	c *Chip8
+}
+
+// Read reads a byte of the emulator's memory
+func (m machineMemory) Read(addr uint16) uint8 {
+	return uint8(m.c.memory[int(addr)%len(m.c.memory)])
+}
+
+// Write writes a byte of the emulator's memory
+func (m machineMemory) Write(addr uint16, value uint8) {
+	m.c.writeMemory(int(addr)%len(m.c.memory), value)
+}
+
+// callMachineCode runs the 1802 subroutine at NNN with the VIP interpreter's register conventions,
+// until it gives control back to the interpreter with D4 (SEP R4)
+func callMachineCode(c *Chip8) error {
+	end := len(c.memory)
+	registers := end - vipRegistersOffset
+	display := end - vipDisplayOffset
+	if registers < 0 {
+		return fmt.Errorf("machine code at 0x%03X needs the VIP interpreter's memory layout", c.opcode&0x0FFF)
+	}
+	hasDisplay := c.platform.Width == 64 && c.platform.Height == 32
+
+	// The interpreter's state is stored where the VIP interpreter keeps it
+	for i, v := range c.registers {
+		c.writeMemory(registers+i, v)
+	}
+	if hasDisplay {
+		for i := 0; i < len(c.gfx)/8; i++ {
+			var b uint8
+			for bit := 0; bit < 8; bit++ {
+				b = b<<1 | c.gfx[i*8+bit]
+			}
+			c.writeMemory(display+i, b)
+		}
+	}
+
+	cpu := cdp1802.New(machineMemory{c})
+	cpu.R[2] = uint16(end - vipStackOffset)
+	cpu.R[3] = c.opcode & 0x0FFF
+	cpu.R[5] = c.pc + 2
+	cpu.R[6] = uint16(registers) + (c.opcode&0x0F00)>>8
+	cpu.R[7] = uint16(registers) + (c.opcode&0x00F0)>>4
+	cpu.R[0xA] = c.i
+	cpu.R[0xB] = uint16(display)
+	cpu.P = 3
+	cpu.X = 2
+
+	for steps := 0; cpu.P != 4; steps++ {
+		if steps == machineCodeStepLimit {
+			return fmt.Errorf("machine code at 0x%03X did not return after %d instructions", c.opcode&0x0FFF, steps)
+		}
+		if err := cpu.Step(); err != nil {
+			return err
+		}
+		// The display interrupt and DMA of the VIP wake up an idle 1802 right away
+		cpu.Idle = false
+	}
+
+	// The subroutine may have changed the interpreter's state
+	for i := range c.registers {
+		c.registers[i] = uint8(c.memory[registers+i])
+	}
+	if hasDisplay {
+		for i := range c.gfx {
+			c.gfx[i] = uint8(c.memory[display+i/8]>>(7-uint(i%8))) & 0x1
+		}
+		c.draw = true
+	}
+	c.i = cpu.R[0xA]
+	c.pc = cpu.R[5]
+	return nil
+}
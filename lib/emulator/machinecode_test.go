@@ -0,0 +1,64 @@
+package emulator
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// hybridROM loads a CHIP-8 program at 0x200 and a machine-code subroutine at 0x300, and runs 0NNN as machine code
+func hybridROM(t *testing.T, program, subroutine []byte) *Chip8 {
+	rom := make([]byte, 0x100+len(subroutine))
+	copy(rom, program)
+	copy(rom[0x100:], subroutine)
+	c := initChip8()
+	assert.Nil(t, c.LoadBytes(rom))
+	c.SetQuirks(Quirks{MachineCode: true})
+	return c
+}
+
+func TestCallMachineCode(t *testing.T) {
+	c := hybridROM(t, []byte{
+		0x63, 0x10, // 200: LD V3, 0x10
+		0xA2, 0x50, // 202: LD I, 0x250
+		0x03, 0x00, // 204: SYS 0x300, R6 points to V3
+		0x12, 0x06, // 206: JP 0x206
+	}, []byte{
+		0x06,       // 300: LDN R6, D = V3
+		0xFC, 0x05, // 301: ADI 0x05
+		0x56,       // 303: STR R6, V3 = D
+		0xF8, 0xAB, // 304: LDI 0xAB
+		0x5B,       // 306: STR RB, the first 8 pixels of the display
+		0x8A,       // 307: GLO RA, the low byte of I
+		0xFC, 0x01, // 308: ADI 0x01
+		0xAA, // 30A: PLO RA
+		0xD4, // 30B: SEP R4, back to the interpreter
+	})
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, c.EmulateCycle())
+	}
+
+	// The subroutine changed V3, I and the display through the interpreter's memory, then returned after 0NNN
+	assert.Equal(t, uint8(0x15), c.registers[0x3])
+	assert.Equal(t, uint8(0x15), c.ReadMemory(uint16(len(c.memory)-vipRegistersOffset+3)))
+	assert.Equal(t, uint16(0x251), c.i)
+	assert.Equal(t, []uint8{1, 0, 1, 0, 1, 0, 1, 1, 0}, c.gfx[:9])
+	assert.True(t, c.draw)
+	assert.Equal(t, uint16(0x206), c.pc)
+}
+
+func TestCallMachineCode_stuck(t *testing.T) {
+	c := hybridROM(t, []byte{
+		0x03, 0x00, // 200: SYS 0x300
+	}, []byte{
+		0x30, 0x00, // 300: BR 0x00, forever
+	})
+	assert.NotNil(t, c.EmulateCycle())
+}
+
+func TestCallMachineCode_disabled(t *testing.T) {
+	// Without the quirk, 0NNN is skipped
+	c := hybridROM(t, []byte{0x03, 0x00}, []byte{0xD4})
+	c.SetQuirks(Quirks{})
+	assert.Nil(t, c.EmulateCycle())
+	assert.Equal(t, uint16(0x202), c.pc)
+}
@@ -13,8 +13,12 @@ func handleOpcode(c *Chip8) error {
 		opcode00EE(c)
 	} else if c.opcode == 0x00E0 {
 		opcode00E0(c)
+	} else if c.opcode == 0x0230 && c.platform.ID == PlatformHiRes.ID {
+		opcode0230(c)
+	} else if c.opcode == 0x02A0 && c.isChip8X() {
+		opcode02A0(c)
 	} else if (c.opcode & 0xF000) == 0x0000 {
-		opcode0NNN(c)
+		return opcode0NNN(c)
 	} else if (c.opcode & 0xF000) == 0x1000 {
 		opcode1NNN(c)
 	} else if (c.opcode & 0xF000) == 0x2000 {
@@ -25,6 +29,8 @@ func handleOpcode(c *Chip8) error {
 		opcode4XNN(c)
 	} else if (c.opcode & 0xF00F) == 0x5000 {
 		opcode5XY0(c)
+	} else if (c.opcode&0xF00F) == 0x5001 && c.isChip8X() {
+		opcode5XY1(c)
 	} else if (c.opcode & 0xF000) == 0x6000 {
 		opcode6XNN(c)
 	} else if (c.opcode & 0xF000) == 0x7000 {
@@ -51,6 +57,10 @@ func handleOpcode(c *Chip8) error {
 		opcode9XY0(c)
 	} else if (c.opcode & 0xF000) == 0xA000 {
 		opcodeANNN(c)
+	} else if (c.opcode&0xF00F) == 0xB000 && c.isChip8X() {
+		opcodeBXY0(c)
+	} else if (c.opcode&0xF000) == 0xB000 && c.isChip8X() {
+		opcodeBXYN(c)
 	} else if (c.opcode & 0xF000) == 0xB000 {
 		opcodeBNNN(c)
 	} else if (c.opcode & 0xF000) == 0xC000 {
@@ -61,6 +71,10 @@ func handleOpcode(c *Chip8) error {
 		opcodeEX9E(c)
 	} else if (c.opcode & 0xF0FF) == 0xE0A1 {
 		opcodeEXA1(c)
+	} else if (c.opcode&0xF0FF) == 0xE0F2 && c.isChip8X() {
+		opcodeEXF2(c)
+	} else if (c.opcode&0xF0FF) == 0xE0F5 && c.isChip8X() {
+		opcodeEXF5(c)
 	} else if (c.opcode & 0xF0FF) == 0xF007 {
 		opcodeFX07(c)
 	} else if (c.opcode & 0xF0FF) == 0xF00A {
@@ -73,12 +87,18 @@ func handleOpcode(c *Chip8) error {
 		opcodeFX1E(c)
 	} else if (c.opcode & 0xF0FF) == 0xF029 {
 		opcodeFX29(c)
+	} else if (c.opcode&0xF0FF) == 0xF030 && len(c.GetFont().Large) > 0 {
+		opcodeFX30(c)
 	} else if (c.opcode & 0xF0FF) == 0xF033 {
 		opcodeFX33(c)
 	} else if (c.opcode & 0xF0FF) == 0xF055 {
 		opcodeFX55(c)
 	} else if (c.opcode & 0xF0FF) == 0xF065 {
 		opcodeFX65(c)
+	} else if (c.opcode&0xF0FF) == 0xF0F8 && c.isChip8X() {
+		opcodeFXF8(c)
+	} else if (c.opcode&0xF0FF) == 0xF0FB && c.isChip8X() {
+		opcodeFXFB(c)
 	} else {
 		return errors.New("Unknow c.opcode found : " + string(c.opcode))
 	}
@@ -100,18 +120,42 @@ func opcode00EE(c *Chip8) {
 // CLS
 // Clear the display.
 func opcode00E0(c *Chip8) {
-	c.gfx = [gfxSize]uint8{}
+	for i := range c.gfx {
+		c.gfx[i] = 0
+	}
 	c.draw = true
 	c.pc = c.pc + 2
 }
 
+// CLS (hi-res CHIP-8)
+// Clear the 64x64 display.
+//
+// The hi-res CHIP-8 interpreter replaces the clear-screen routine of the COSMAC VIP with its own, called at 0x230.
+func opcode0230(c *Chip8) {
+	opcode00E0(c)
+}
+
+// BGC (CHIP-8X)
+// Cycle the background color.
+//
+// The background color changes to the next of dark blue, black, green and red.
+func opcode02A0(c *Chip8) {
+	c.background = (c.background + 1) % chip8XBackgrounds
+	c.draw = true
+	c.pc += 2
+}
+
 // SYS addr
 // Jump to a machine code routine at nnn.
 //
 // This instruction is only used on the old computers on which Chip-8 was originally implemented.
-// It is ignored by modern interpreters.
-func opcode0NNN(c *Chip8) {
+// It is ignored by modern interpreters, unless the MachineCode quirk is set to run the RCA 1802 routine.
+func opcode0NNN(c *Chip8) error {
+	if c.quirks.MachineCode {
+		return callMachineCode(c)
+	}
 	c.pc += 2
+	return nil
 }
 
 // JP addr
@@ -172,6 +216,18 @@ func opcode5XY0(c *Chip8) {
 	c.pc += nByteJump
 }
 
+// ADD Vx, Vy (CHIP-8X)
+// Set Vx = Vx + Vy, nibble by nibble.
+//
+// The high nibbles of Vx and Vy are added together, and so are the low nibbles.
+// The carries are lost and VF is left unchanged.
+func opcode5XY1(c *Chip8) {
+	x := c.registers[(c.opcode&0x0F00)>>8]
+	y := c.registers[(c.opcode&0x00F0)>>4]
+	c.registers[(c.opcode&0x0F00)>>8] = (x&0xF0+y&0xF0)&0xF0 | (x+y)&0x0F
+	c.pc += 2
+}
+
 // LD Vx, byte
 // Set Vx = kk.
 //
@@ -207,6 +263,9 @@ func opcode8XY0(c *Chip8) {
 // then the same bit in the result is also 1. Otherwise, it is 0.
 func opcode8XY1(c *Chip8) {
 	c.registers[(c.opcode&0x0F00)>>8] = c.registers[(c.opcode&0x0F00)>>8] | c.registers[(c.opcode&0x00F0)>>4]
+	if c.quirks.ResetVF {
+		c.registers[0xF] = 0
+	}
 	c.pc += 2
 }
 
@@ -218,6 +277,9 @@ func opcode8XY1(c *Chip8) {
 // then the same bit in the result is also 1. Otherwise, it is 0.
 func opcode8XY2(c *Chip8) {
 	c.registers[(c.opcode&0x0F00)>>8] = c.registers[(c.opcode&0x0F00)>>8] & c.registers[(c.opcode&0x00F0)>>4]
+	if c.quirks.ResetVF {
+		c.registers[0xF] = 0
+	}
 	c.pc += 2
 }
 
@@ -230,6 +292,9 @@ func opcode8XY2(c *Chip8) {
 // Otherwise, it is 0.
 func opcode8XY3(c *Chip8) {
 	c.registers[(c.opcode&0x0F00)>>8] = c.registers[(c.opcode&0x0F00)>>8] ^ c.registers[(c.opcode&0x00F0)>>4]
+	if c.quirks.ResetVF {
+		c.registers[0xF] = 0
+	}
 	c.pc += 2
 }
 
@@ -269,8 +334,12 @@ func opcode8XY5(c *Chip8) {
 //
 // If the least-significant bit of Vx is 1, then VF is set to 1, otherwise 0.
 // Then Vx is divided by 2.
+// With the ShiftVY quirk, Vy is shifted and the result is stored in Vx.
 func opcode8XY6(c *Chip8) {
 	x := (c.opcode & 0x0F00) >> 8
+	if c.quirks.ShiftVY {
+		c.registers[x] = c.registers[(c.opcode&0x00F0)>>4]
+	}
 	c.registers[0xF] = c.registers[x] & 0x1
 	c.registers[x] >>= 1
 	c.pc += 2
@@ -296,7 +365,11 @@ func opcode8XY7(c *Chip8) {
 //
 // If the most-significant bit of Vx is 1, then VF is set to 1, otherwise to 0.
 // Then Vx is multiplied by 2.
+// With the ShiftVY quirk, Vy is shifted and the result is stored in Vx.
 func opcode8XYE(c *Chip8) {
+	if c.quirks.ShiftVY {
+		c.registers[(c.opcode&0x0F00)>>8] = c.registers[(c.opcode&0x00F0)>>4]
+	}
 	c.registers[0xF] = c.registers[(c.opcode&0x0F00)>>8] >> 7
 	c.registers[(c.opcode&0x0F00)>>8] = c.registers[(c.opcode&0x0F00)>>8] << 1
 	c.pc += 2
@@ -328,10 +401,44 @@ func opcodeANNN(c *Chip8) {
 // Jump to location nnn + V0.
 //
 // The program counter is set to nnn plus the value of V0.
+// With the Jump quirk, the program counter is set to xnn plus the value of Vx.
 func opcodeBNNN(c *Chip8) {
+	if c.quirks.Jump {
+		c.pc = (c.opcode & 0x0FFF) + uint16(c.registers[(c.opcode&0x0F00)>>8])
+		return
+	}
 	c.pc = (c.opcode & 0x0FFF) + uint16(c.registers[0x0])
 }
 
+// COL Vx, Vy (CHIP-8X)
+// Set the foreground color of an area to Vy.
+//
+// The low nibble of Vx is the first column of the area in units of 8 pixels, its high nibble the number of additional columns.
+// The low nibble of Vx+1 is the first row of the area in units of 4 pixels, its high nibble the number of additional rows.
+// The area is set to the color of the low 3 bits of Vy.
+func opcodeBXY0(c *Chip8) {
+	x := (c.opcode & 0x0F00) >> 8
+	horizontal := c.registers[x]
+	vertical := c.registers[(x+1)&0xF]
+	c.setColorZones(int(horizontal&0xF), int(vertical&0xF)*4, int(horizontal>>4)+1, (int(vertical>>4)+1)*4, c.registers[(c.opcode&0x00F0)>>4])
+	c.draw = true
+	c.pc += 2
+}
+
+// COL Vx, Vy, nibble (CHIP-8X)
+// Set the foreground color of an area of n rows at (Vx, Vy) to Vx+1.
+//
+// The area is the 8 pixels wide column holding Vx, from row Vy to row Vy + n - 1.
+// It is set to the color of the low 3 bits of Vx+1.
+func opcodeBXYN(c *Chip8) {
+	x := (c.opcode & 0x0F00) >> 8
+	column := int(c.registers[x]) % c.platform.Width / 8
+	row := int(c.registers[(c.opcode&0x00F0)>>4]) % c.platform.Height
+	c.setColorZones(column, row, 1, int(c.opcode&0x000F), c.registers[(x+1)&0xF])
+	c.draw = true
+	c.pc += 2
+}
+
 // RND Vx, byte
 // Set Vx = random byte AND kk.
 //
@@ -348,33 +455,44 @@ func opcodeCXNN(c *Chip8) {
 // The interpreter reads n bytes from memory, starting at the address stored in I.
 // These bytes are then displayed as sprites on screen at coordinates (Vx, Vy).
 // Sprites are XORed onto the existing screen. If this causes any pixels to be erased, VF is set to 1,
-// otherwise it is set to 0. If the sprite is positioned so part of it is outside the coordinates
-// of the display, it wraps around to the opposite side of the screen.
+// otherwise it is set to 0. The sprite starts at (Vx, Vy) modulo the size of the screen. Parts of the sprite
+// outside the coordinates of the display are clipped, or wrap around to the opposite side of the screen
+// with the Wrap quirk.
 // See instruction 8xy3 for more information on XOR, and section 2.4, Display, for more information
 // on the Chip-8 screen and sprites.
 func opcodeDXYN(c *Chip8) {
-	x := uint16(c.registers[(c.opcode&0x0F00)>>8])
-	y := uint16(c.registers[(c.opcode&0x00F0)>>4])
+	gfxWidth, gfxHeight := uint16(c.platform.Width), uint16(c.platform.Height)
+	x := uint16(c.registers[(c.opcode&0x0F00)>>8]) % gfxWidth
+	y := uint16(c.registers[(c.opcode&0x00F0)>>4]) % gfxHeight
 	height := c.opcode & 0x000F
 	c.registers[0xF] = 0
+	sprite := c.recordSprite(int(x), int(y), int(height))
 	var yLine uint16
 	var xLine uint16
 	for yLine = 0; yLine < height; yLine++ {
 		pixel := c.memory[c.i+yLine]
 		for xLine = 0; xLine < 8; xLine++ {
-			if (pixel & (0x80 >> xLine)) != 0 {
-				// Avoid overflowing chip8's memory
-				if x+xLine+((y+yLine)*64) >= gfxSize {
+			if (pixel & (0x80 >> xLine)) == 0 {
+				continue
+			}
+			xPixel, yPixel := x+xLine, y+yLine
+			if xPixel >= gfxWidth || yPixel >= gfxHeight {
+				if !c.quirks.Wrap {
 					continue
 				}
-				if c.gfx[(x+xLine+((y+yLine)*64))] == 1 {
-					c.registers[0xF] = 1
+				xPixel, yPixel = xPixel%gfxWidth, yPixel%gfxHeight
+			}
+			if c.gfx[xPixel+yPixel*gfxWidth] == 1 {
+				c.registers[0xF] = 1
+				if sprite != nil {
+					sprite.Collisions = append(sprite.Collisions, int(xPixel+yPixel*gfxWidth))
 				}
-				c.gfx[x+xLine+((y+yLine)*64)] ^= 1
 			}
+			c.gfx[xPixel+yPixel*gfxWidth] ^= 1
 		}
 	}
 	c.draw = true
+	c.vblankWait = c.quirks.VBlank
 	c.pc += 2
 }
 
@@ -404,6 +522,26 @@ func opcodeEXA1(c *Chip8) {
 	c.pc += nByteJump
 }
 
+// SKP2 Vx (CHIP-8X)
+// Skip next instruction if key with the value of Vx is pressed on the second keypad.
+func opcodeEXF2(c *Chip8) {
+	var nByteJump uint16 = 2
+	if c.key2[c.registers[(c.opcode&0x0F00)>>8]&0xF] == 1 {
+		nByteJump += 2
+	}
+	c.pc += nByteJump
+}
+
+// SKNP2 Vx (CHIP-8X)
+// Skip next instruction if key with the value of Vx is not pressed on the second keypad.
+func opcodeEXF5(c *Chip8) {
+	var nByteJump uint16 = 2
+	if c.key2[c.registers[(c.opcode&0x0F00)>>8]&0xF] == 0 {
+		nByteJump += 2
+	}
+	c.pc += nByteJump
+}
+
 // LD Vx, DT
 // Set Vx = delay timer value.
 //
@@ -470,7 +608,17 @@ func opcodeFX1E(c *Chip8) {
 // The value of I is set to the location for the hexadecimal sprite corresponding to the value of Vx.
 // See section 2.4, Display, for more information on the Chip-8 hexadecimal font.
 func opcodeFX29(c *Chip8) {
-	c.i = uint16(c.registers[(c.opcode&0x0F00)>>8]) * 0x5
+	c.i = c.platform.FontAddress + uint16(c.registers[(c.opcode&0x0F00)>>8])*smallGlyphSize
+	c.pc += 2
+}
+
+// LD HF, Vx
+// Set I = location of large sprite for digit Vx.
+//
+// The value of I is set to the location for the 8x10 sprite of the large font corresponding to the value of Vx.
+// The large font is stored right after the small one, it only exists when the loaded font has one.
+func opcodeFX30(c *Chip8) {
+	c.i = c.platform.FontAddress + fontSetSize + uint16(c.registers[(c.opcode&0x0F00)>>8])*largeGlyphSize
 	c.pc += 2
 }
 
@@ -499,9 +647,9 @@ func opcodeFX33(c *Chip8) {
 	}
 
 	// write to memory
-	c.memory[c.i+0] = (b >> 8) & 0xF
-	c.memory[c.i+1] = (b >> 4) & 0xF
-	c.memory[c.i+2] = (b >> 0) & 0xF
+	c.writeMemory(int(c.i)+0, uint8(b>>8)&0xF)
+	c.writeMemory(int(c.i)+1, uint8(b>>4)&0xF)
+	c.writeMemory(int(c.i)+2, uint8(b>>0)&0xF)
 	c.pc += 2
 }
 
@@ -509,10 +657,12 @@ func opcodeFX33(c *Chip8) {
 // Store registers V0 through Vx in memory starting at location I.
 //
 // The interpreter copies the values of registers V0 through Vx into memory, starting at the address in I.
+// I is left unchanged, unless the IncrementI or IncrementIByX quirks are set.
 func opcodeFX55(c *Chip8) {
 	for i := 0; i < int((c.opcode&0x0F00)>>8)+1; i++ {
-		c.memory[uint16(i)+c.i] = uint16(c.registers[i])
+		c.writeMemory(int(uint16(i)+c.i), c.registers[i])
 	}
+	incrementI(c)
 	c.pc += 2
 }
 
@@ -520,9 +670,46 @@ func opcodeFX55(c *Chip8) {
 // Read registers V0 through Vx from memory starting at location I.
 //
 // The interpreter reads values from memory starting at location I into registers V0 through Vx.
+// I is left unchanged, unless the IncrementI or IncrementIByX quirks are set.
 func opcodeFX65(c *Chip8) {
 	for i := 0; i < int((c.opcode&0x0F00)>>8)+1; i++ {
 		c.registers[i] = uint8(c.memory[c.i+uint16(i)])
 	}
+	incrementI(c)
+	c.pc += 2
+}
+
+// incrementI moves I after FX55 and FX65, according to the quirks
+func incrementI(c *Chip8) {
+	x := (c.opcode & 0x0F00) >> 8
+	if c.quirks.IncrementI {
+		c.i += x + 1
+	} else if c.quirks.IncrementIByX {
+		c.i += x
+	}
+}
+
+// OUT Vx (CHIP-8X)
+// Output Vx to the I/O port.
+func opcodeFXF8(c *Chip8) {
+	if c.port != nil {
+		c.port.Output(c.registers[(c.opcode&0x0F00)>>8])
+	}
+	c.pc += 2
+}
+
+// IN Vx (CHIP-8X)
+// Wait for a byte from the I/O port, and store it in Vx.
+//
+// The instruction is executed again until the port has a byte available.
+func opcodeFXFB(c *Chip8) {
+	value := uint8(0)
+	if c.port != nil {
+		var ok bool
+		if value, ok = c.port.Input(); !ok {
+			return
+		}
+	}
+	c.registers[(c.opcode&0x0F00)>>8] = value
 	c.pc += 2
 }
@@ -0,0 +1,91 @@
+package emulator
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Platform describes the memory layout and the display of a machine running CHIP-8 programs
+type Platform struct {
+	ID          string
+	Name        string
+	LoadAddress uint16
+	StartPC     uint16
+	FontAddress uint16
+	Font        string
+	MemorySize  int
+	Width       int
+	Height      int
+}
+
+// Memory layouts and displays of the machines CHIP-8 ran on
+var (
+	// PlatformVIP is the COSMAC VIP, CHIP-8's original machine
+	PlatformVIP = Platform{ID: "vip", Name: "COSMAC VIP", LoadAddress: 0x200, StartPC: 0x200, FontAddress: 0x000, Font: "vip", MemorySize: 4096, Width: 64, Height: 32}
+	// PlatformETI660 is the ETI-660, which loads programs after its interpreter at 0x600
+	PlatformETI660 = Platform{ID: "eti660", Name: "ETI-660", LoadAddress: 0x600, StartPC: 0x600, FontAddress: 0x000, Font: "eti660", MemorySize: 4096, Width: 64, Height: 32}
+	// PlatformDREAM6800 is the DREAM 6800 running CHIPOS, with its memory expanded to 4K
+	PlatformDREAM6800 = Platform{ID: "dream6800", Name: "DREAM 6800", LoadAddress: 0x200, StartPC: 0x200, FontAddress: 0x000, Font: "dream6800", MemorySize: 4096, Width: 64, Height: 32}
+	// PlatformChip8X is the COSMAC VIP with the VP-590 color board and CHIP-8X, which loads programs at 0x300
+	PlatformChip8X = Platform{ID: "chip8x", Name: "CHIP-8X", LoadAddress: 0x300, StartPC: 0x300, FontAddress: 0x000, Font: "vip", MemorySize: 4096, Width: 64, Height: 32}
+	// PlatformHiRes is the 64x64 hi-res CHIP-8 of the COSMAC VIP, whose programs start after the interpreter's patch at 0x2C0
+	PlatformHiRes = Platform{ID: "hires", Name: "COSMAC VIP hi-res CHIP-8", LoadAddress: 0x200, StartPC: 0x2C0, FontAddress: 0x000, Font: "vip", MemorySize: 4096, Width: 64, Height: 64}
+)
+
+// Platforms are the platforms known by the emulator, by ID
+var Platforms = map[string]Platform{
+	PlatformVIP.ID:       PlatformVIP,
+	PlatformETI660.ID:    PlatformETI660,
+	PlatformDREAM6800.ID: PlatformDREAM6800,
+	PlatformHiRes.ID:     PlatformHiRes,
+	PlatformChip8X.ID:    PlatformChip8X,
+}
+
+// PlatformIDs gets the IDs of the known platforms, sorted
+func PlatformIDs() []string {
+	var ids []string
+	for id := range Platforms {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// validate checks that the platform's addresses fit in its memory
+func (p Platform) validate() error {
+	if p.MemorySize <= 0 || p.MemorySize > 0x10000 {
+		return fmt.Errorf("platform %s: invalid memory size %d", p.ID, p.MemorySize)
+	}
+	if int(p.LoadAddress) >= p.MemorySize || int(p.StartPC) >= p.MemorySize {
+		return fmt.Errorf("platform %s: programs must load and start within its %d bytes of memory", p.ID, p.MemorySize)
+	}
+	if p.Width <= 0 || p.Height <= 0 || p.Width > 256 || p.Height > 256 {
+		return fmt.Errorf("platform %s: invalid display of %dx%d pixels", p.ID, p.Width, p.Height)
+	}
+	f, ok := Fonts[p.Font]
+	if !ok {
+		return fmt.Errorf("platform %s: unknown font %q", p.ID, p.Font)
+	}
+	if int(p.FontAddress)+f.Size() > p.MemorySize {
+		return fmt.Errorf("platform %s: the font at 0x%03X does not fit in memory", p.ID, p.FontAddress)
+	}
+	return nil
+}
+
+// SetPlatform sets the memory layout and the display of the emulator, which is reset to apply them
+func (c *Chip8) SetPlatform(p Platform) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+	if c.font != nil && int(p.FontAddress)+c.font.Size() > p.MemorySize {
+		return fmt.Errorf("platform %s: font %s does not fit in memory at 0x%03X", p.ID, c.font.ID, p.FontAddress)
+	}
+	c.platform = p
+	c.Initialize(c.beeper)
+	return nil
+}
+
+// GetPlatform gets the memory layout and the display of the emulator
+func (c *Chip8) GetPlatform() Platform {
+	return c.platform
+}
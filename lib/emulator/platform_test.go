@@ -0,0 +1,69 @@
+package emulator
+
+import (
+	"github.com/mlemesle/chip-go-8/lib/romfile"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSetPlatform(t *testing.T) {
+	for _, p := range Platforms {
+		c := New()
+		assert.Nil(t, c.SetPlatform(p))
+		assert.Nil(t, c.LoadBytes([]byte{0x00, 0xE0, 0x12, 0x34}))
+		assert.Equal(t, uint16(0x00), c.memory[p.LoadAddress], p.ID)
+		assert.Equal(t, uint16(0xE0), c.memory[p.LoadAddress+1], p.ID)
+		assert.Equal(t, uint16(0x34), c.memory[p.LoadAddress+3], p.ID)
+		assert.Equal(t, p.StartPC, c.pc, p.ID)
+		assert.Equal(t, p.MemorySize, len(c.memory), p.ID)
+	}
+	assert.Equal(t, uint16(0x600), PlatformETI660.LoadAddress)
+	assert.Equal(t, uint16(0x300), PlatformChip8X.StartPC)
+	assert.Equal(t, uint16(0x2C0), PlatformHiRes.StartPC)
+}
+
+func TestSetPlatform_invalid(t *testing.T) {
+	p := PlatformVIP
+	p.LoadAddress = 0x1000
+	assert.Error(t, New().SetPlatform(p))
+
+	p = PlatformVIP
+	p.Font = "unknown"
+	assert.Error(t, New().SetPlatform(p))
+
+	// The font must fit between its address and the end of memory
+	p = PlatformVIP
+	p.FontAddress = 0xFC0
+	assert.EqualError(t, New().SetPlatform(p), "platform vip: the font at 0xFC0 does not fit in memory")
+}
+
+func TestLoadBytes_tooBig(t *testing.T) {
+	c := New()
+	assert.Nil(t, c.SetPlatform(PlatformETI660))
+	assert.Nil(t, c.LoadBytes(make([]byte, 4096-0x600)))
+	assert.EqualError(t, c.LoadBytes(make([]byte, 4096-0x600+1)), "rom of 2561 bytes is too big for memory, at most 2560 bytes can be loaded")
+}
+
+func TestLoadAt(t *testing.T) {
+	c := New()
+	assert.Nil(t, c.SetPlatform(PlatformETI660))
+	assert.Nil(t, c.LoadAt([]byte{0x12, 0x34}, 0x600))
+	assert.Equal(t, uint16(0x12), c.memory[0x600])
+	assert.Nil(t, c.LoadAt([]byte{0x12, 0x34}, romfile.NoOrigin))
+	assert.EqualError(t, c.LoadAt([]byte{0x12, 0x34}, 0x200), "rom starts at 0x200 but platform eti660 loads roms at 0x600")
+}
+
+func TestSetFont_doesNotFit(t *testing.T) {
+	p := PlatformVIP
+	p.FontAddress = 0xFA0
+	c := New()
+	assert.Nil(t, c.SetPlatform(p))
+	assert.EqualError(t, c.SetFont(FontXOCHIP), "font xochip does not fit in memory at 0xFA0")
+	assert.Equal(t, FontVIP.ID, c.GetFont().ID)
+
+	// A font set before is checked against the new platform
+	c = initChip8()
+	assert.Nil(t, c.SetFont(FontXOCHIP))
+	assert.EqualError(t, c.SetPlatform(p), "platform vip: font xochip does not fit in memory at 0xFA0")
+	assert.Equal(t, uint16(0x000), c.GetPlatform().FontAddress)
+}
@@ -0,0 +1,32 @@
+package emulator
+
+// Quirks are the behaviours that differ between CHIP-8 interpreters
+// The zero value is the emulator's historical behaviour.
+type Quirks struct {
+	// ShiftVY makes 8XY6 and 8XYE shift VY into VX, instead of shifting VX in place
+	ShiftVY bool
+	// IncrementI makes FX55 and FX65 leave I at I + X + 1
+	IncrementI bool
+	// IncrementIByX makes FX55 and FX65 leave I at I + X
+	IncrementIByX bool
+	// Wrap makes sprites wrap around the edges of the screen, instead of being clipped
+	Wrap bool
+	// Jump makes BXNN jump to XNN + VX, instead of NNN + V0
+	Jump bool
+	// VBlank makes DXYN wait for the end of the frame
+	VBlank bool
+	// ResetVF makes 8XY1, 8XY2 and 8XY3 reset VF
+	ResetVF bool
+	// MachineCode makes 0NNN run the RCA 1802 machine-code subroutine at NNN, as hybrid COSMAC VIP programs need
+	MachineCode bool
+}
+
+// SetQuirks sets the quirks the emulator follows
+func (c *Chip8) SetQuirks(q Quirks) {
+	c.quirks = q
+}
+
+// GetQuirks gets the quirks the emulator follows
+func (c *Chip8) GetQuirks() Quirks {
+	return c.quirks
+}
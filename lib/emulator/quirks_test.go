@@ -0,0 +1,196 @@
+package emulator
+
+import (
+	"github.com/mlemesle/chip-go-8/lib/beeper"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func initChip8Quirks(q Quirks) *Chip8 {
+	c := initChip8()
+	c.SetQuirks(q)
+	return c
+}
+
+func TestQuirks_ShiftVY(t *testing.T) {
+	c := initChip8Quirks(Quirks{})
+	c.opcode = 0x8AB6
+	c.registers[0xA] = 0x04
+	c.registers[0xB] = 0x03
+	opcode8XY6(c)
+	assert.Equal(t, uint8(0x02), c.registers[0xA])
+	assert.Equal(t, uint8(0x00), c.registers[0xF])
+
+	c = initChip8Quirks(Quirks{ShiftVY: true})
+	c.opcode = 0x8AB6
+	c.registers[0xA] = 0x04
+	c.registers[0xB] = 0x03
+	opcode8XY6(c)
+	assert.Equal(t, uint8(0x01), c.registers[0xA])
+	assert.Equal(t, uint8(0x03), c.registers[0xB])
+	assert.Equal(t, uint8(0x01), c.registers[0xF])
+
+	c = initChip8Quirks(Quirks{})
+	c.opcode = 0x8ABE
+	c.registers[0xA] = 0x01
+	c.registers[0xB] = 0x81
+	opcode8XYE(c)
+	assert.Equal(t, uint8(0x02), c.registers[0xA])
+	assert.Equal(t, uint8(0x00), c.registers[0xF])
+
+	c = initChip8Quirks(Quirks{ShiftVY: true})
+	c.opcode = 0x8ABE
+	c.registers[0xA] = 0x01
+	c.registers[0xB] = 0x81
+	opcode8XYE(c)
+	assert.Equal(t, uint8(0x02), c.registers[0xA])
+	assert.Equal(t, uint8(0x81), c.registers[0xB])
+	assert.Equal(t, uint8(0x01), c.registers[0xF])
+}
+
+func TestQuirks_IncrementI(t *testing.T) {
+	for q, expected := range map[Quirks]uint16{
+		{}:                    0x300,
+		{IncrementI: true}:    0x304,
+		{IncrementIByX: true}: 0x303,
+	} {
+		c := initChip8Quirks(q)
+		c.opcode = 0xF355
+		c.i = 0x300
+		opcodeFX55(c)
+		assert.Equal(t, expected, c.i, "FX55 %+v", q)
+		assert.Equal(t, uint16(0x202), c.pc)
+
+		c = initChip8Quirks(q)
+		c.opcode = 0xF365
+		c.i = 0x300
+		opcodeFX65(c)
+		assert.Equal(t, expected, c.i, "FX65 %+v", q)
+		assert.Equal(t, uint16(0x202), c.pc)
+	}
+}
+
+func TestQuirks_Jump(t *testing.T) {
+	c := initChip8Quirks(Quirks{})
+	c.opcode = 0xB345
+	c.registers[0x0] = 0x01
+	c.registers[0x3] = 0x10
+	opcodeBNNN(c)
+	assert.Equal(t, uint16(0x346), c.pc)
+
+	c = initChip8Quirks(Quirks{Jump: true})
+	c.opcode = 0xB345
+	c.registers[0x0] = 0x01
+	c.registers[0x3] = 0x10
+	opcodeBNNN(c)
+	assert.Equal(t, uint16(0x355), c.pc)
+}
+
+func TestQuirks_ResetVF(t *testing.T) {
+	for _, opcode := range []func(*Chip8){opcode8XY1, opcode8XY2, opcode8XY3} {
+		c := initChip8Quirks(Quirks{})
+		c.opcode = 0x8AB1
+		c.registers[0xF] = 0x01
+		opcode(c)
+		assert.Equal(t, uint8(0x01), c.registers[0xF])
+
+		c = initChip8Quirks(Quirks{ResetVF: true})
+		c.opcode = 0x8AB1
+		c.registers[0xF] = 0x01
+		opcode(c)
+		assert.Equal(t, uint8(0x00), c.registers[0xF])
+	}
+}
+
+func TestQuirks_Wrap(t *testing.T) {
+	// A 2 pixels wide sprite drawn on the last column of the last row
+	draw := func(q Quirks) *Chip8 {
+		c := initChip8Quirks(q)
+		w, h := c.GetResolution()
+		c.opcode = 0xDAB2
+		c.registers[0xA] = uint8(w - 1)
+		c.registers[0xB] = uint8(h - 1)
+		c.i = 0x300
+		c.memory[0x300] = 0xC0
+		c.memory[0x301] = 0xC0
+		opcodeDXYN(c)
+		return c
+	}
+
+	c := draw(Quirks{})
+	w, h := c.GetResolution()
+	assert.Equal(t, uint8(1), c.gfx[w*h-1])
+	assert.Equal(t, uint8(0), c.gfx[0])
+	assert.Equal(t, uint8(0), c.gfx[(h-1)*w])
+	assert.Equal(t, uint8(0), c.gfx[w-1])
+	assert.Equal(t, 1, count(c.gfx))
+
+	c = draw(Quirks{Wrap: true})
+	assert.Equal(t, uint8(1), c.gfx[w*h-1])
+	assert.Equal(t, uint8(1), c.gfx[0])
+	assert.Equal(t, uint8(1), c.gfx[(h-1)*w])
+	assert.Equal(t, uint8(1), c.gfx[w-1])
+	assert.Equal(t, 4, count(c.gfx))
+}
+
+func TestOpcode_DXYN_startModulo(t *testing.T) {
+	// Whatever the quirks, a sprite starts at its position modulo the size of the screen
+	c := initChip8()
+	w, h := c.GetResolution()
+	c.opcode = 0xDAB1
+	c.registers[0xA] = uint8(w + 2)
+	c.registers[0xB] = uint8(h + 1)
+	c.i = 0x300
+	c.memory[0x300] = 0x80
+	opcodeDXYN(c)
+	assert.Equal(t, uint8(1), c.gfx[2+w])
+	assert.Equal(t, 1, count(c.gfx))
+}
+
+func TestQuirks_VBlank(t *testing.T) {
+	// A loop drawing a sprite and counting in V0
+	rom := []uint16{0xD0, 0x01, 0x70, 0x01, 0x12, 0x00}
+	run := func(q Quirks) *Chip8 {
+		c := initChip8Quirks(q)
+		copy(c.memory[c.pc:], rom)
+		assert.Nil(t, c.EmulateFrame(9))
+		return c
+	}
+
+	c := run(Quirks{})
+	assert.Equal(t, uint8(3), c.registers[0x0])
+	assert.Equal(t, uint16(0x200), c.pc)
+
+	// The frame ends after the draw, and the next one starts where it stopped
+	c = run(Quirks{VBlank: true})
+	assert.Equal(t, uint8(0), c.registers[0x0])
+	assert.Equal(t, uint16(0x202), c.pc)
+	assert.Nil(t, c.EmulateFrame(9))
+	assert.Equal(t, uint8(1), c.registers[0x0])
+	assert.Equal(t, uint16(0x202), c.pc)
+}
+
+func TestEmulateFrame_timers(t *testing.T) {
+	// The timers tick once per frame, whatever the number of cycles
+	c := initChip8()
+	c.beeper = beeper.NewMute()
+	copy(c.memory[c.pc:], []uint16{0x12, 0x00})
+	c.delayTimer = 3
+	c.soundTimer = 2
+	assert.Nil(t, c.EmulateFrame(10))
+	assert.Equal(t, uint8(2), c.delayTimer)
+	assert.Equal(t, uint8(1), c.soundTimer)
+	assert.Nil(t, c.EmulateFrame(1))
+	assert.Nil(t, c.EmulateFrame(1))
+	assert.Equal(t, uint8(0), c.delayTimer)
+	assert.Equal(t, uint8(0), c.soundTimer)
+}
+
+// count counts the pixels set in a gfx
+func count(gfx []uint8) int {
+	n := 0
+	for _, p := range gfx {
+		n += int(p)
+	}
+	return n
+}
@@ -0,0 +1,44 @@
+package emulator
+
+// Sprite is a sprite drawn by DXYN, as recorded for debugging
+type Sprite struct {
+	// X and Y are the position of the sprite on the display, Width and Height its size in pixels
+	X      int
+	Y      int
+	Width  int
+	Height int
+	// Address is the address of the sprite's data, I when it was drawn, and PC the address of the DXYN
+	Address uint16
+	PC      uint16
+	// Wrap tells if the parts of the sprite past the edges of the display wrapped around, instead of being clipped
+	Wrap bool
+	// Collisions are the pixels the sprite turned off, setting VF, as indexes of GetGFX
+	Collisions []int
+}
+
+// RecordSprites starts or stops recording the sprites drawn during each frame
+func (c *Chip8) RecordSprites(record bool) {
+	c.recordSprites = record
+	if !record {
+		c.sprites = nil
+	}
+}
+
+// Sprites gets the sprites drawn during the last frame that drew any, in order, when they are recorded
+// Frames drawing nothing keep the sprites of the frame before, so they stay shown while the display does not change.
+func (c *Chip8) Sprites() []Sprite {
+	return c.sprites
+}
+
+// recordSprite records a sprite drawn by DXYN, if sprites are recorded
+// The collisions of the sprite are added to the sprite returned, which is nil when sprites are not recorded.
+func (c *Chip8) recordSprite(x, y, height int) *Sprite {
+	if !c.recordSprites {
+		return nil
+	}
+	if c.newSprites {
+		c.sprites, c.newSprites = nil, false
+	}
+	c.sprites = append(c.sprites, Sprite{X: x, Y: y, Width: 8, Height: height, Address: c.i, PC: c.pc, Wrap: c.quirks.Wrap})
+	return &c.sprites[len(c.sprites)-1]
+}
@@ -0,0 +1,42 @@
+package emulator
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRecordSprites(t *testing.T) {
+	c := initChip8Quirks(Quirks{VBlank: true})
+	assert.Nil(t, c.LoadBytes([]byte{
+		0x60, 0x45, // 200: LD V0, 0x45, column 5 once wrapped
+		0x61, 0x03, // 202: LD V1, 0x03
+		0xA2, 0x10, // 204: LD I, 0x210
+		0xD0, 0x12, // 206: DRW V0, V1, 2, ending the first frame
+		0xD0, 0x11, // 208: DRW V0, V1, 1, turning off the pixel at (5, 3)
+		0x12, 0x0A, // 20A: JP 0x20A
+		0x00, 0x00, // 20C: padding
+		0x00, 0x00, // 20E: padding
+		0x80, 0x80, // 210: the sprite
+	}))
+
+	// Nothing is recorded until asked
+	assert.Nil(t, c.EmulateFrame(10))
+	assert.Nil(t, c.Sprites())
+
+	assert.Nil(t, c.Reset())
+	c.RecordSprites(true)
+	assert.Nil(t, c.EmulateFrame(10))
+	assert.Equal(t, []Sprite{{X: 5, Y: 3, Width: 8, Height: 2, Address: 0x210, PC: 0x206}}, c.Sprites())
+
+	// The first sprite of the next frame starts a new list
+	assert.Nil(t, c.EmulateFrame(10))
+	assert.Equal(t, []Sprite{{X: 5, Y: 3, Width: 8, Height: 1, Address: 0x210, PC: 0x208, Collisions: []int{5 + 3*64}}}, c.Sprites())
+
+	// Frames drawing nothing keep it
+	assert.Nil(t, c.EmulateFrame(10))
+	assert.Len(t, c.Sprites(), 1)
+	assert.Equal(t, uint16(0x208), c.Sprites()[0].PC)
+
+	c.RecordSprites(false)
+	assert.Nil(t, c.Sprites())
+}
@@ -0,0 +1,133 @@
+package octo
+
+import (
+	"math"
+)
+
+// unaryOperators are the functions of calc expressions
+var unaryOperators = map[string]func(float64) float64{
+	"-":     func(x float64) float64 { return -x },
+	"~":     func(x float64) float64 { return float64(^int64(x)) },
+	"!":     func(x float64) float64 { return truth(x == 0) },
+	"sin":   math.Sin,
+	"cos":   math.Cos,
+	"tan":   math.Tan,
+	"exp":   math.Exp,
+	"log":   math.Log,
+	"abs":   math.Abs,
+	"sqrt":  math.Sqrt,
+	"sign":  sign,
+	"ceil":  math.Ceil,
+	"floor": math.Floor,
+}
+
+// binaryOperators are the operators of calc expressions
+var binaryOperators = map[string]func(float64, float64) float64{
+	"-":   func(x, y float64) float64 { return x - y },
+	"+":   func(x, y float64) float64 { return x + y },
+	"*":   func(x, y float64) float64 { return x * y },
+	"/":   func(x, y float64) float64 { return x / y },
+	"%":   math.Mod,
+	"&":   func(x, y float64) float64 { return float64(int64(x) & int64(y)) },
+	"|":   func(x, y float64) float64 { return float64(int64(x) | int64(y)) },
+	"^":   func(x, y float64) float64 { return float64(int64(x) ^ int64(y)) },
+	"<<":  func(x, y float64) float64 { return float64(int64(x) << uint64(y)) },
+	">>":  func(x, y float64) float64 { return float64(int64(x) >> uint64(y)) },
+	"pow": math.Pow,
+	"min": math.Min,
+	"max": math.Max,
+	"<":   func(x, y float64) float64 { return truth(x < y) },
+	"<=":  func(x, y float64) float64 { return truth(x <= y) },
+	"==":  func(x, y float64) float64 { return truth(x == y) },
+	"!=":  func(x, y float64) float64 { return truth(x != y) },
+	">=":  func(x, y float64) float64 { return truth(x >= y) },
+	">":   func(x, y float64) float64 { return truth(x > y) },
+}
+
+func truth(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func sign(x float64) float64 {
+	if x < 0 {
+		return -1
+	} else if x > 0 {
+		return 1
+	}
+	return 0
+}
+
+// calc evaluates the expression up to the closing brace
+// As in Octo, operators have no precedence and are evaluated from right to left: 2 * 3 + 1 is 8.
+func (a *assembler) calc() float64 {
+	v := a.expression()
+	a.expect("}")
+	return v
+}
+
+// expression evaluates a term and the operators following it
+func (a *assembler) expression() float64 {
+	x := a.term()
+	t := a.peek()
+	if t.quoted || t.text == "}" || t.text == ")" {
+		return x
+	}
+	op, ok := binaryOperators[t.text]
+	if !ok {
+		a.next()
+		a.fail("expected an operator, not %q", t.text)
+	}
+	a.next()
+	return op(x, a.expression())
+}
+
+// term evaluates a value, an expression between parentheses or a function of a term
+func (a *assembler) term() float64 {
+	t := a.next()
+	if t.quoted {
+		a.fail("expected a value, not the string %q", t.text)
+	}
+	if t.text == "(" {
+		v := a.expression()
+		a.expect(")")
+		return v
+	}
+	if op, ok := unaryOperators[t.text]; ok {
+		return op(a.term())
+	}
+	switch t.text {
+	case "@":
+		// The byte already assembled at an address
+		addr := int(a.term())
+		if addr < Start || addr >= Start+a.size {
+			a.fail("0x%X is not in the program", addr)
+		}
+		return float64(a.rom[addr-Start])
+	case "strlen":
+		s := a.next()
+		if !s.quoted {
+			a.fail("expected a string after strlen, not %q", s.text)
+		}
+		return float64(len(s.text))
+	case "HERE":
+		return float64(a.here)
+	case "PI":
+		return math.Pi
+	case "E":
+		return math.E
+	}
+	if n, ok := number(t.text); ok {
+		return n
+	}
+	if v, ok := a.consts[t.text]; ok {
+		return v
+	}
+	if addr, ok := a.labels[t.text]; ok {
+		return float64(addr)
+	}
+	a.fail("%q is not defined", t.text)
+	return 0
+}
@@ -0,0 +1,891 @@
+package octo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Start is the address where programs are loaded
+const Start = 0x200
+
+// maxExpansions bounds the macros expanded by a program, so that a macro calling itself fails
+const maxExpansions = 100000
+
+// token is a word of the source, or the text of a string between double quotes
+type token struct {
+	text   string
+	line   int
+	quoted bool
+}
+
+// fixup kinds, telling how the address of a label defined later is written
+const (
+	fixupNNN    = iota // the low 12 bits of an instruction
+	fixupUnpack        // the v0 := and v1 := of :unpack, the high byte keeping its nibble
+)
+
+// fixup is a reference to a label defined later
+type fixup struct {
+	addr int
+	kind int
+	line int
+}
+
+// block is an if or a loop waiting for its end or again
+type block struct {
+	kind   string // "if", "else" or "loop"
+	addr   int    // the jump to patch for if and else, the start of a loop
+	whiles []int  // the jumps of the whiles leaving a loop
+	line   int
+}
+
+// macro is a :macro and the number of times it was expanded
+type macro struct {
+	args  []string
+	body  []token
+	calls int
+}
+
+// stringMode is a :stringmode, expanding its body for each character of its alphabet in a string
+type stringMode struct {
+	alphabet string
+	body     []token
+	calls    int
+}
+
+// assemblyError is raised by fail and returned by Assemble
+type assemblyError struct {
+	err error
+}
+
+// assembler holds the state of a program being assembled
+type assembler struct {
+	tokens     []token
+	line       int
+	rom        []byte
+	size       int
+	here       int
+	labels     map[string]int
+	consts     map[string]float64
+	aliases    map[string]int
+	macros     map[string]*macro
+	modes      map[string][]*stringMode
+	fixups     map[string][]fixup
+	blocks     []*block
+	expansions int
+}
+
+// keywords are the words of the language, which can't name labels, constants or macros
+var keywords = map[string]bool{
+	":": true, ":next": true, ":unpack": true, ":breakpoint": true, ":monitor": true, ":alias": true,
+	":const": true, ":org": true, ":byte": true, ":call": true, ":macro": true, ":calc": true,
+	":stringmode": true, ":assert": true, ";": true, "return": true, "clear": true, "bcd": true,
+	"save": true, "load": true, "sprite": true, "jump": true, "jump0": true, "native": true,
+	"delay": true, "buzzer": true, "pitch": true, "i": true, "if": true, "then": true, "begin": true,
+	"else": true, "end": true, "loop": true, "while": true, "again": true, "key": true, "-key": true,
+	"hex": true, "bighex": true, "long": true, "random": true, ":=": true, "+=": true, "-=": true,
+	"=-": true, "|=": true, "&=": true, "^=": true, ">>=": true, "<<=": true, "==": true, "!=": true,
+	"<": true, ">": true, "<=": true, ">=": true, "{": true, "}": true, "-": true, "hires": true,
+	"lores": true, "scroll-up": true, "scroll-down": true, "scroll-left": true, "scroll-right": true,
+	"exit": true, "saveflags": true, "loadflags": true, "plane": true, "audio": true,
+}
+
+// operators are the register operations of the 8XYN instructions
+var operators = map[string]byte{"|=": 0x1, "&=": 0x2, "^=": 0x3, "+=": 0x4, "-=": 0x5, ">>=": 0x6, "=-": 0x7, "<<=": 0xE}
+
+// negations are the comparisons holding when the others don't
+var negations = map[string]string{
+	"==": "!=", "!=": "==", "key": "-key", "-key": "key", "<": ">=", ">": "<=", "<=": ">", ">=": "<",
+}
+
+// Assemble compiles the Octo source of a program into a rom loaded at Start
+// Like Octo, the program starts with a jump to its main label.
+func Assemble(source string) ([]byte, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	a := &assembler{
+		tokens:  tokens,
+		rom:     make([]byte, 0x10000-Start),
+		here:    Start,
+		labels:  map[string]int{},
+		consts:  map[string]float64{},
+		aliases: map[string]int{"unpack-hi": 0x0, "unpack-lo": 0x1, "compare-temp": 0xF},
+		macros:  map[string]*macro{},
+		modes:   map[string][]*stringMode{},
+		fixups:  map[string][]fixup{},
+	}
+	return a.assemble()
+}
+
+// tokenize splits the source into tokens, dropping the comments from # to the end of the line
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	line := 1
+	for i := 0; i < len(source); {
+		switch c := source[i]; {
+		case c == '\n':
+			line++
+			i++
+		case isSpace(c):
+			i++
+		case c == '#':
+			for i < len(source) && source[i] != '\n' {
+				i++
+			}
+		case c == '"':
+			start := line
+			var text strings.Builder
+			for i++; ; i++ {
+				if i >= len(source) {
+					return nil, fmt.Errorf("line %d: missing the closing \" of the string", start)
+				}
+				c = source[i]
+				if c == '"' {
+					i++
+					break
+				}
+				if c == '\n' {
+					line++
+				} else if c == '\\' && i+1 < len(source) {
+					i++
+					c = map[byte]byte{'n': '\n', 'r': '\r', 't': '\t', 'v': '\v', '0': 0}[source[i]]
+					if c == 0 && source[i] != '0' {
+						c = source[i]
+					}
+				}
+				text.WriteByte(c)
+			}
+			tokens = append(tokens, token{text: text.String(), line: start, quoted: true})
+		default:
+			start := i
+			for i < len(source) && !isSpace(source[i]) && source[i] != '\n' {
+				i++
+			}
+			tokens = append(tokens, token{text: source[start:i], line: line})
+		}
+	}
+	return tokens, nil
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\v' || c == '\f'
+}
+
+// number parses a decimal, 0x hexadecimal or 0b binary number, negative when it starts with -
+func number(text string) (float64, bool) {
+	s := strings.TrimPrefix(text, "-")
+	sign := 1.0
+	if len(s) < len(text) {
+		sign = -1
+	}
+	if len(s) > 2 && (strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X")) {
+		n, err := strconv.ParseUint(s[2:], 16, 32)
+		return sign * float64(n), err == nil
+	}
+	if len(s) > 2 && (strings.HasPrefix(s, "0b") || strings.HasPrefix(s, "0B")) {
+		n, err := strconv.ParseUint(s[2:], 2, 32)
+		return sign * float64(n), err == nil
+	}
+	if s == "" || strings.Trim(s, "0123456789.") != "" {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	return sign * n, err == nil
+}
+
+// fail stops the assembly with an error at the line of the last token read
+func (a *assembler) fail(format string, args ...interface{}) {
+	a.failAt(a.line, format, args...)
+}
+
+// failAt stops the assembly with an error at the given line
+func (a *assembler) failAt(line int, format string, args ...interface{}) {
+	panic(assemblyError{fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))})
+}
+
+// assemble assembles the statements up to the end of the program, returning the first error
+func (a *assembler) assemble() (rom []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(assemblyError)
+			if !ok {
+				panic(r)
+			}
+			rom, err = nil, e.err
+		}
+	}()
+
+	a.addressed(0x1, token{text: "main", line: 1})
+	for len(a.tokens) > 0 {
+		a.statement()
+	}
+	if len(a.blocks) > 0 {
+		b := a.blocks[len(a.blocks)-1]
+		if b.kind == "loop" {
+			a.failAt(b.line, "loop without again")
+		}
+		a.failAt(b.line, "if without end")
+	}
+	if _, ok := a.fixups["main"]; ok {
+		return nil, fmt.Errorf("the program has no main label")
+	}
+	// Report the first reference to a label never defined
+	first := ""
+	for name, fixups := range a.fixups {
+		if f := a.fixups[first]; first == "" || fixups[0].line < f[0].line || fixups[0].line == f[0].line && name < first {
+			first = name
+		}
+	}
+	if first != "" {
+		a.failAt(a.fixups[first][0].line, "%q is not defined", first)
+	}
+	return a.rom[:a.size], nil
+}
+
+// next reads the next token
+func (a *assembler) next() token {
+	if len(a.tokens) == 0 {
+		a.fail("unexpected end of the program")
+	}
+	t := a.tokens[0]
+	a.tokens = a.tokens[1:]
+	a.line = t.line
+	return t
+}
+
+// peek gets the next token without reading it, or an empty token at the end of the program
+func (a *assembler) peek() token {
+	if len(a.tokens) == 0 {
+		return token{line: a.line}
+	}
+	return a.tokens[0]
+}
+
+// expect reads the next token, which must be text
+func (a *assembler) expect(text string) {
+	if t := a.next(); t.text != text || t.quoted {
+		a.fail("expected %s, not %q", text, t.text)
+	}
+}
+
+// insert puts tokens before the next token, expanding a macro
+func (a *assembler) insert(tokens []token) {
+	a.expansions++
+	if a.expansions > maxExpansions {
+		a.fail("too many macros expanded, a macro may expand itself")
+	}
+	a.tokens = append(tokens, a.tokens...)
+}
+
+// emit writes bytes at the current address
+func (a *assembler) emit(bytes ...byte) {
+	for _, b := range bytes {
+		if a.here >= Start+len(a.rom) {
+			a.fail("the program is larger than 64K")
+		}
+		a.rom[a.here-Start] = b
+		a.here++
+		if a.here-Start > a.size {
+			a.size = a.here - Start
+		}
+	}
+}
+
+// registerOf gets the register named by a token, v0 to vF or an alias
+func (a *assembler) registerOf(t token) (byte, bool) {
+	if t.quoted {
+		return 0, false
+	}
+	if r, ok := a.aliases[t.text]; ok {
+		return byte(r), true
+	}
+	if len(t.text) == 2 && (t.text[0] == 'v' || t.text[0] == 'V') {
+		r, err := strconv.ParseUint(t.text[1:], 16, 4)
+		return byte(r), err == nil
+	}
+	return 0, false
+}
+
+// register reads a register
+func (a *assembler) register() byte {
+	t := a.next()
+	r, ok := a.registerOf(t)
+	if !ok {
+		a.fail("expected a register, not %q", t.text)
+	}
+	return r
+}
+
+// isName tells whether a token can name a label, a constant or a macro
+func (a *assembler) isName(t token) bool {
+	if _, ok := a.registerOf(t); ok || t.quoted || t.text == "" || keywords[t.text] {
+		return false
+	}
+	_, ok := number(t.text)
+	return !ok
+}
+
+// value reads a number, a constant, a label or a calc expression between braces
+func (a *assembler) value() float64 {
+	t := a.next()
+	if t.quoted {
+		a.fail("expected a number, not the string %q", t.text)
+	}
+	if t.text == "{" {
+		return a.calc()
+	}
+	if n, ok := number(t.text); ok {
+		return n
+	}
+	if v, ok := a.consts[t.text]; ok {
+		return v
+	}
+	if addr, ok := a.labels[t.text]; ok {
+		return float64(addr)
+	}
+	if a.isName(t) {
+		a.fail("%q is not defined", t.text)
+	}
+	a.fail("expected a number, not %q", t.text)
+	return 0
+}
+
+// integer reads a value between min and max
+func (a *assembler) integer(min, max int, what string) int {
+	v := a.value()
+	n := int(v)
+	if v < 0 && float64(n) != v {
+		n--
+	}
+	if n < min || n > max {
+		a.fail("%d does not fit in %s", n, what)
+	}
+	return n
+}
+
+// byteValue reads a value fitting in a byte, negative values being written as two's complement
+func (a *assembler) byteValue() byte {
+	return byte(a.integer(-128, 255, "a byte"))
+}
+
+// nibble reads a value fitting in 4 bits
+func (a *assembler) nibble() byte {
+	return byte(a.integer(0, 15, "4 bits"))
+}
+
+// inst emits a 2-byte instruction
+func (a *assembler) inst(hi, lo byte) {
+	a.emit(hi, lo)
+}
+
+// instNNN reads an address and emits the instruction op with it as NNN
+func (a *assembler) instNNN(op byte) {
+	addr := a.address(fixupNNN)
+	a.inst(op<<4|byte(addr>>8), byte(addr))
+}
+
+// addressed emits the instruction op with the address of a label as NNN
+func (a *assembler) addressed(op byte, label token) {
+	addr := a.labelAddress(label, fixupNNN)
+	a.inst(op<<4|byte(addr>>8), byte(addr))
+}
+
+// address reads a 12-bit address, which may be a label
+func (a *assembler) address(kind int) int {
+	t := a.peek()
+	if _, isConst := a.consts[t.text]; a.isName(t) && !isConst {
+		return a.labelAddress(a.next(), kind)
+	}
+	return a.integer(0, 0xFFF, "12 bits")
+}
+
+// labelAddress gets the address of a label, or 0 with a fixup at the current address when it is not defined yet
+func (a *assembler) labelAddress(label token, kind int) int {
+	addr, ok := a.labels[label.text]
+	if !ok {
+		a.fixups[label.text] = append(a.fixups[label.text], fixup{addr: a.here, kind: kind, line: label.line})
+		return 0
+	}
+	if addr > 0xFFF {
+		a.failAt(label.line, "%q is at 0x%X, which does not fit in 12 bits", label.text, addr)
+	}
+	return addr
+}
+
+// define gives a name to an address, writing the references made to it before
+func (a *assembler) define(name token, addr int) {
+	if !a.isName(name) {
+		a.fail("%q can't name a label", name.text)
+	}
+	if _, ok := a.labels[name.text]; ok {
+		a.fail("the label %q is already defined", name.text)
+	}
+	if _, ok := a.consts[name.text]; ok {
+		a.fail("%q is already a constant", name.text)
+	}
+	a.labels[name.text] = addr
+	for _, f := range a.fixups[name.text] {
+		at := f.addr - Start
+		switch f.kind {
+		case fixupNNN:
+			if addr > 0xFFF {
+				a.failAt(f.line, "%q is at 0x%X, which does not fit in 12 bits", name.text, addr)
+			}
+			a.rom[at] = a.rom[at]&0xF0 | byte(addr>>8)
+			a.rom[at+1] = byte(addr)
+		case fixupUnpack:
+			if addr > 0xFFF {
+				a.failAt(f.line, "%q is at 0x%X, which does not fit in 12 bits", name.text, addr)
+			}
+			a.rom[at+1] = a.rom[at+1]&0xF0 | byte(addr>>8)
+			a.rom[at+3] = byte(addr)
+		}
+	}
+	delete(a.fixups, name.text)
+}
+
+// block reads the tokens up to the closing brace matching an opening brace already read
+func (a *assembler) block() []token {
+	var body []token
+	for depth := 1; ; {
+		t := a.next()
+		if !t.quoted && t.text == "{" {
+			depth++
+		} else if !t.quoted && t.text == "}" {
+			depth--
+			if depth == 0 {
+				return body
+			}
+		}
+		body = append(body, t)
+	}
+}
+
+// substitute copies a macro's body, replacing the words naming its arguments
+func substitute(body []token, args map[string]token) []token {
+	tokens := make([]token, len(body))
+	for i, t := range body {
+		if arg, ok := args[t.text]; ok && !t.quoted {
+			t = arg
+		}
+		tokens[i] = t
+	}
+	return tokens
+}
+
+// numberToken makes a token of a number given to a macro
+func numberToken(n int) token {
+	return token{text: strconv.Itoa(n)}
+}
+
+// statement assembles the next statement
+func (a *assembler) statement() {
+	t := a.next()
+	if t.quoted {
+		a.fail("unexpected string %q", t.text)
+	}
+	if x, ok := a.registerOf(t); ok {
+		a.assignment(x)
+		return
+	}
+	switch t.text {
+	case ":":
+		a.define(a.next(), a.here)
+	case ":next":
+		a.define(a.next(), a.here+1)
+	case ":alias":
+		name := a.next()
+		if !a.isName(name) {
+			a.fail("%q can't name a register", name.text)
+		}
+		a.aliases[name.text] = int(a.register())
+	case ":const", ":calc":
+		name := a.next()
+		if !a.isName(name) {
+			a.fail("%q can't name a constant", name.text)
+		}
+		if _, ok := a.labels[name.text]; ok {
+			a.fail("%q is already a label", name.text)
+		}
+		if t.text == ":calc" {
+			a.expect("{")
+			a.consts[name.text] = a.calc()
+		} else {
+			a.consts[name.text] = a.value()
+		}
+	case ":org":
+		a.here = a.integer(Start, Start+len(a.rom)-1, "the memory after 0x200")
+	case ":byte":
+		a.emit(a.byteValue())
+	case ":call":
+		a.instNNN(0x2)
+	case ":unpack":
+		a.unpack()
+	case ":breakpoint":
+		a.next()
+	case ":monitor":
+		a.next()
+		a.next()
+	case ":assert":
+		message := "assertion failed"
+		if p := a.peek(); p.quoted {
+			message = a.next().text
+		}
+		a.expect("{")
+		if a.calc() == 0 {
+			a.fail("%s", message)
+		}
+	case ":macro":
+		name := a.next()
+		if !a.isName(name) {
+			a.fail("%q can't name a macro", name.text)
+		}
+		m := &macro{}
+		for arg := a.next(); arg.text != "{"; arg = a.next() {
+			m.args = append(m.args, arg.text)
+		}
+		m.body = a.block()
+		a.macros[name.text] = m
+	case ":stringmode":
+		name := a.next()
+		if !a.isName(name) {
+			a.fail("%q can't name a string mode", name.text)
+		}
+		alphabet := a.next()
+		if !alphabet.quoted {
+			a.fail("expected the string of the characters of %s, not %q", name.text, alphabet.text)
+		}
+		a.expect("{")
+		a.modes[name.text] = append(a.modes[name.text], &stringMode{alphabet: alphabet.text, body: a.block()})
+	case ";", "return":
+		a.inst(0x00, 0xEE)
+	case "clear":
+		a.inst(0x00, 0xE0)
+	case "bcd":
+		a.inst(0xF0|a.register(), 0x33)
+	case "save", "load":
+		x := a.register()
+		if a.peek().text == "-" {
+			a.unsupported(t.text + " vx - vy")
+		}
+		if t.text == "save" {
+			a.inst(0xF0|x, 0x55)
+		} else {
+			a.inst(0xF0|x, 0x65)
+		}
+	case "sprite":
+		x := a.register()
+		y := a.register()
+		a.inst(0xD0|x, y<<4|a.nibble())
+	case "jump":
+		a.instNNN(0x1)
+	case "jump0":
+		a.instNNN(0xB)
+	case "native":
+		a.instNNN(0x0)
+	case "delay":
+		a.expect(":=")
+		a.inst(0xF0|a.register(), 0x15)
+	case "buzzer":
+		a.expect(":=")
+		a.inst(0xF0|a.register(), 0x18)
+	case "pitch", "hires", "lores", "scroll-down", "scroll-up", "scroll-right", "scroll-left", "exit",
+		"saveflags", "loadflags", "plane", "audio":
+		a.unsupported(t.text)
+	case "i":
+		a.index()
+	case "if":
+		c := a.condition()
+		switch k := a.next(); k.text {
+		case "then":
+			a.skip(c)
+		case "begin":
+			c.op = negations[c.op]
+			a.skip(c)
+			a.blocks = append(a.blocks, &block{kind: "if", addr: a.here, line: t.line})
+			a.inst(0x10, 0x00)
+		default:
+			a.fail("expected then or begin, not %q", k.text)
+		}
+	case "else":
+		b := a.top(t.text, "if", "else")
+		jump := a.here
+		a.inst(0x10, 0x00)
+		a.patchJump(b.addr, a.here)
+		b.kind, b.addr = "else", jump
+	case "end":
+		b := a.top(t.text, "if", "else")
+		a.patchJump(b.addr, a.here)
+		a.blocks = a.blocks[:len(a.blocks)-1]
+	case "loop":
+		a.blocks = append(a.blocks, &block{kind: "loop", addr: a.here, line: t.line})
+	case "while":
+		var loop *block
+		for i := len(a.blocks) - 1; i >= 0 && loop == nil; i-- {
+			if a.blocks[i].kind == "loop" {
+				loop = a.blocks[i]
+			}
+		}
+		if loop == nil {
+			a.fail("while outside of a loop")
+		}
+		c := a.condition()
+		c.op = negations[c.op]
+		a.skip(c)
+		loop.whiles = append(loop.whiles, a.here)
+		a.inst(0x10, 0x00)
+	case "again":
+		b := a.top(t.text, "loop")
+		a.inst(0x10|byte(b.addr>>8&0xF), byte(b.addr))
+		for _, w := range b.whiles {
+			a.patchJump(w, a.here)
+		}
+		a.blocks = a.blocks[:len(a.blocks)-1]
+	default:
+		a.word(t)
+	}
+}
+
+// unsupported stops the assembly at a SUPER-CHIP or XO-CHIP statement, which none of the platforms runs
+func (a *assembler) unsupported(statement string) {
+	a.fail("%s is a SUPER-CHIP or XO-CHIP statement, the emulator does not run it", statement)
+}
+
+// word assembles a statement starting with a word that is not a keyword: a byte, a macro or a call
+func (a *assembler) word(t token) {
+	if m, ok := a.macros[t.text]; ok {
+		args := map[string]token{"CALLS": numberToken(m.calls)}
+		for _, name := range m.args {
+			args[name] = a.next()
+		}
+		m.calls++
+		a.insert(substitute(m.body, args))
+		return
+	}
+	if modes, ok := a.modes[t.text]; ok {
+		a.expandString(t.text, modes)
+		return
+	}
+	if _, ok := number(t.text); ok {
+		a.tokens = append([]token{t}, a.tokens...)
+		a.emit(a.byteValue())
+		return
+	}
+	if _, ok := a.consts[t.text]; ok {
+		a.tokens = append([]token{t}, a.tokens...)
+		a.emit(a.byteValue())
+		return
+	}
+	if strings.HasPrefix(t.text, ":") && len(t.text) > 1 {
+		a.fail("unknown directive %q", t.text)
+	}
+	if !a.isName(t) {
+		a.fail("unexpected %q", t.text)
+	}
+	// A label alone calls the subroutine at the label
+	a.addressed(0x2, t)
+}
+
+// expandString expands the string modes for each character of the string read next
+func (a *assembler) expandString(name string, modes []*stringMode) {
+	s := a.next()
+	if !s.quoted {
+		a.fail("expected a string after %s, not %q", name, s.text)
+	}
+	var tokens []token
+	for i := 0; i < len(s.text); i++ {
+		var mode *stringMode
+		value := -1
+		for _, m := range modes {
+			if value = strings.IndexByte(m.alphabet, s.text[i]); value >= 0 {
+				mode = m
+				break
+			}
+		}
+		if mode == nil {
+			a.fail("%q is not a character of %s", s.text[i], name)
+		}
+		tokens = append(tokens, substitute(mode.body, map[string]token{
+			"VALUE": numberToken(value),
+			"CHAR":  numberToken(int(s.text[i])),
+			"INDEX": numberToken(i),
+			"CALLS": numberToken(mode.calls),
+		})...)
+		mode.calls++
+	}
+	a.insert(tokens)
+}
+
+// assignment assembles the statement of a register x
+func (a *assembler) assignment(x byte) {
+	op := a.next()
+	if op.text == ":=" {
+		switch a.peek().text {
+		case "random":
+			a.next()
+			a.inst(0xC0|x, a.byteValue())
+		case "key":
+			a.next()
+			a.inst(0xF0|x, 0x0A)
+		case "delay":
+			a.next()
+			a.inst(0xF0|x, 0x07)
+		default:
+			if y, ok := a.registerOf(a.peek()); ok {
+				a.next()
+				a.inst(0x80|x, y<<4)
+			} else {
+				a.inst(0x60|x, a.byteValue())
+			}
+		}
+		return
+	}
+	n, ok := operators[op.text]
+	if !ok || op.quoted {
+		a.fail("expected an assignment, not %q", op.text)
+	}
+	if y, ok := a.registerOf(a.peek()); ok {
+		a.next()
+		a.inst(0x80|x, y<<4|n)
+	} else if op.text == "+=" {
+		a.inst(0x70|x, a.byteValue())
+	} else if op.text == "-=" {
+		a.inst(0x70|x, -a.byteValue())
+	} else {
+		a.fail("%s needs a register", op.text)
+	}
+}
+
+// index assembles the statements of i
+func (a *assembler) index() {
+	switch op := a.next(); op.text {
+	case "+=":
+		a.inst(0xF0|a.register(), 0x1E)
+	case ":=":
+		switch a.peek().text {
+		case "hex":
+			a.next()
+			a.inst(0xF0|a.register(), 0x29)
+		case "bighex", "long":
+			a.unsupported("i := " + a.peek().text)
+		default:
+			a.instNNN(0xA)
+		}
+	default:
+		a.fail("expected := or += after i, not %q", op.text)
+	}
+}
+
+// unpack assembles :unpack, loading the address of a label in the registers unpack-hi and unpack-lo
+// The high byte holds a nibble before the 12-bit address.
+func (a *assembler) unpack() {
+	if a.peek().text == "long" {
+		a.unsupported(":unpack long")
+	}
+	nibble := a.nibble()
+	addr := a.address(fixupUnpack)
+	a.inst(0x60|byte(a.aliases["unpack-hi"]), nibble<<4|byte(addr>>8))
+	a.inst(0x60|byte(a.aliases["unpack-lo"]), byte(addr))
+}
+
+// top gets the innermost block for the statement read, which must be of one of the given kinds
+func (a *assembler) top(statement string, kinds ...string) *block {
+	if len(a.blocks) > 0 {
+		b := a.blocks[len(a.blocks)-1]
+		for _, k := range kinds {
+			if b.kind == k {
+				return b
+			}
+		}
+	}
+	if kinds[0] == "loop" {
+		a.fail("%s without loop", statement)
+	}
+	a.fail("%s without if ... begin", statement)
+	return nil
+}
+
+// patchJump sets the address of the jump at addr
+func (a *assembler) patchJump(addr, target int) {
+	if target > 0xFFF {
+		a.fail("0x%X does not fit in 12 bits", target)
+	}
+	a.rom[addr-Start] = 0x10 | byte(target>>8)
+	a.rom[addr-Start+1] = byte(target)
+}
+
+// condition is the comparison of an if or a while
+type condition struct {
+	x        byte
+	op       string
+	register bool
+	operand  byte
+}
+
+// condition reads a comparison
+func (a *assembler) condition() condition {
+	c := condition{x: a.register()}
+	op := a.next()
+	c.op = op.text
+	if _, ok := negations[c.op]; !ok || op.quoted {
+		a.fail("expected a comparison, not %q", op.text)
+	}
+	if c.op == "key" || c.op == "-key" {
+		return c
+	}
+	if y, ok := a.registerOf(a.peek()); ok {
+		a.next()
+		c.register, c.operand = true, y
+	} else {
+		c.operand = a.byteValue()
+	}
+	return c
+}
+
+// skip emits the instructions running the next instruction only when the condition holds
+// The comparisons of order subtract in the register compare-temp, vF unless it is aliased.
+func (a *assembler) skip(c condition) {
+	x := c.x
+	switch c.op {
+	case "==":
+		if c.register {
+			a.inst(0x90|x, c.operand<<4)
+		} else {
+			a.inst(0x40|x, c.operand)
+		}
+	case "!=":
+		if c.register {
+			a.inst(0x50|x, c.operand<<4)
+		} else {
+			a.inst(0x30|x, c.operand)
+		}
+	case "key":
+		a.inst(0xE0|x, 0xA1)
+	case "-key":
+		a.inst(0xE0|x, 0x9E)
+	default:
+		tmp := byte(a.aliases["compare-temp"])
+		if c.register {
+			a.inst(0x80|tmp, c.operand<<4)
+		} else {
+			a.inst(0x60|tmp, c.operand)
+		}
+		switch c.op {
+		case ">":
+			a.inst(0x80|tmp, x<<4|0x5)
+			a.inst(0x30|tmp, 1)
+		case "<":
+			a.inst(0x80|tmp, x<<4|0x7)
+			a.inst(0x30|tmp, 1)
+		case ">=":
+			a.inst(0x80|tmp, x<<4|0x7)
+			a.inst(0x40|tmp, 1)
+		case "<=":
+			a.inst(0x80|tmp, x<<4|0x5)
+			a.inst(0x40|tmp, 1)
+		}
+	}
+}
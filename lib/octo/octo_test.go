@@ -0,0 +1,174 @@
+package octo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// assemble assembles a program which must have no error
+func assemble(t *testing.T, source string) []byte {
+	rom, err := Assemble(source)
+	assert.Nil(t, err)
+	return rom
+}
+
+func TestAssemble(t *testing.T) {
+	rom := assemble(t, `
+: main         # 200: jump main
+  v0 := 5      # 202
+  v1 += 2      # 204
+  i := hex v0  # 206
+  sprite v0 v1 5
+  loop again   # 20A
+`)
+	assert.Equal(t, []byte{0x12, 0x02, 0x60, 0x05, 0x71, 0x02, 0xF0, 0x29, 0xD0, 0x15, 0x12, 0x0A}, rom)
+}
+
+func TestAssemble_labels(t *testing.T) {
+	// Labels may be used before they are defined, and a label alone calls it
+	rom := assemble(t, `
+: main
+  draw
+  jump main
+: draw
+  i := dot
+  sprite v0 v0 1
+;
+: dot 0x80
+`)
+	assert.Equal(t, []byte{0x12, 0x02, 0x22, 0x06, 0x12, 0x02, 0xA2, 0x0C, 0xD0, 0x01, 0x00, 0xEE, 0x80}, rom)
+}
+
+func TestAssemble_instructions(t *testing.T) {
+	rom := assemble(t, `
+: main
+  v1 := v2  v3 -= 1  v4 -= v5  v6 =- v7  v8 |= v9  va &= vb  vc ^= vd  ve >>= ve  vF <<= v0
+  v0 := random 0xFF  v1 := key  v2 := delay  delay := v3  buzzer := v4  i += v5
+  bcd v6  save v7  load v8  v0 := -1  clear  return  jump0 0x300  native 0x123
+`)
+	assert.Equal(t, []byte{
+		0x12, 0x02,
+		0x81, 0x20, 0x73, 0xFF, 0x84, 0x55, 0x86, 0x77, 0x88, 0x91, 0x8A, 0xB2, 0x8C, 0xD3, 0x8E, 0xE6, 0x8F, 0x0E,
+		0xC0, 0xFF, 0xF1, 0x0A, 0xF2, 0x07, 0xF3, 0x15, 0xF4, 0x18, 0xF5, 0x1E,
+		0xF6, 0x33, 0xF7, 0x55, 0xF8, 0x65, 0x60, 0xFF, 0x00, 0xE0, 0x00, 0xEE, 0xB3, 0x00, 0x01, 0x23,
+	}, rom)
+}
+
+func TestAssemble_conditions(t *testing.T) {
+	// The instruction after then runs when the condition holds, comparisons of order subtract in vF
+	rom := assemble(t, `
+: main
+  if v0 == 1 then clear
+  if v0 != v1 then clear
+  if v2 key then clear
+  if v2 -key then clear
+  if v3 > 4 then clear
+  if v3 < v4 then clear
+  if v3 >= 4 then clear
+  if v3 <= 4 then clear
+`)
+	assert.Equal(t, []byte{
+		0x12, 0x02,
+		0x40, 0x01, 0x00, 0xE0,
+		0x50, 0x10, 0x00, 0xE0,
+		0xE2, 0xA1, 0x00, 0xE0,
+		0xE2, 0x9E, 0x00, 0xE0,
+		0x6F, 0x04, 0x8F, 0x35, 0x3F, 0x01, 0x00, 0xE0,
+		0x8F, 0x40, 0x8F, 0x37, 0x3F, 0x01, 0x00, 0xE0,
+		0x6F, 0x04, 0x8F, 0x37, 0x4F, 0x01, 0x00, 0xE0,
+		0x6F, 0x04, 0x8F, 0x35, 0x4F, 0x01, 0x00, 0xE0,
+	}, rom)
+}
+
+func TestAssemble_blocks(t *testing.T) {
+	rom := assemble(t, `
+: main
+  if v0 == 1 begin   # 202: skip the jump to else when the condition holds
+    v1 := 1
+  else               # 208: jump to end
+    v1 := 2
+  end
+  loop               # 20C
+    v2 += 1
+    while v2 != 10   # 20E: skip the jump after again while the condition holds
+    v3 += 1
+  again
+`)
+	assert.Equal(t, []byte{
+		0x12, 0x02,
+		0x30, 0x01, 0x12, 0x0A, 0x61, 0x01, 0x12, 0x0C, 0x61, 0x02,
+		0x72, 0x01, 0x42, 0x0A, 0x12, 0x16, 0x73, 0x01, 0x12, 0x0C,
+	}, rom)
+}
+
+func TestAssemble_directives(t *testing.T) {
+	rom := assemble(t, `
+:const SPEED 3
+:alias px v4
+:calc STEP { SPEED * 2 + 1 }
+: main
+  px := SPEED
+  px += STEP              # 3 * (2 + 1)
+  :unpack 0xA data        # 206: v0 and v1 make i := data
+  i := data  i := data    # 20A
+: self
+  :next operand v1 := 7   # 20E
+  i := operand
+  :call self
+:org 0x214
+: data
+  SPEED :byte { data - 0x200 } :byte 0b101
+:assert "data is after main" { data > main }
+`)
+	assert.Equal(t, []byte{
+		0x12, 0x02,
+		0x64, 0x03, 0x74, 0x09, 0x60, 0xA2, 0x61, 0x14, 0xA2, 0x14, 0xA2, 0x14,
+		0x61, 0x07, 0xA2, 0x0F, 0x22, 0x0E,
+		0x03, 0x14, 0x05,
+	}, rom)
+}
+
+func TestAssemble_macros(t *testing.T) {
+	rom := assemble(t, `
+:macro twice reg { reg += 1 reg += 1 }
+:macro count { :byte CALLS }
+:stringmode text "ab" { :byte { VALUE + INDEX * 16 } }
+: main
+  twice v2
+  count count
+  text "ba"
+`)
+	assert.Equal(t, []byte{0x12, 0x02, 0x72, 0x01, 0x72, 0x01, 0x00, 0x01, 0x01, 0x10}, rom)
+}
+
+func TestAssemble_errors(t *testing.T) {
+	for source, expected := range map[string]string{
+		"":                                "the program has no main label",
+		": main jump nowhere":             `line 1: "nowhere" is not defined`,
+		": main\n if v0 == 1 begin\n":     "line 2: if without end",
+		": main\n loop\n":                 "line 2: loop without again",
+		": main\n end":                    "line 2: end without if ... begin",
+		": main\n v0 := 256":              "line 2: 256 does not fit in a byte",
+		": main\n sprite v0 v1 16":        "line 2: 16 does not fit in 4 bits",
+		": main\n : main":                 `line 2: the label "main" is already defined`,
+		": main\n v0 :=":                  "line 2: unexpected end of the program",
+		": main\n v0 := v1 v2 v3":         `line 2: expected an assignment, not "v3"`,
+		": main :proto":                   `line 1: unknown directive ":proto"`,
+		": main :assert \"no\" { 1 > 2 }": "line 1: no",
+		": main\n\"abc":                   `line 2: missing the closing " of the string`,
+		":macro m { m } : main m":         "line 1: too many macros expanded, a macro may expand itself",
+		": main\n hires":                  "line 2: hires is a SUPER-CHIP or XO-CHIP statement, the emulator does not run it",
+		": main\n scroll-down 4":          "line 2: scroll-down is a SUPER-CHIP or XO-CHIP statement, the emulator does not run it",
+		": main\n plane 3":                "line 2: plane is a SUPER-CHIP or XO-CHIP statement, the emulator does not run it",
+		": main\n pitch := v0":            "line 2: pitch is a SUPER-CHIP or XO-CHIP statement, the emulator does not run it",
+		": main\n saveflags v7":           "line 2: saveflags is a SUPER-CHIP or XO-CHIP statement, the emulator does not run it",
+		": main\n i := long main":         "line 2: i := long is a SUPER-CHIP or XO-CHIP statement, the emulator does not run it",
+		": main\n i := bighex v0":         "line 2: i := bighex is a SUPER-CHIP or XO-CHIP statement, the emulator does not run it",
+		": main\n save v1 - v3":           "line 2: save vx - vy is a SUPER-CHIP or XO-CHIP statement, the emulator does not run it",
+		": main\n load v1 - v3":           "line 2: load vx - vy is a SUPER-CHIP or XO-CHIP statement, the emulator does not run it",
+		": main\n :unpack long main":      "line 2: :unpack long is a SUPER-CHIP or XO-CHIP statement, the emulator does not run it",
+	} {
+		_, err := Assemble(source)
+		assert.EqualError(t, err, expected, source)
+	}
+}
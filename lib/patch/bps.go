@@ -0,0 +1,206 @@
+package patch
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+)
+
+const (
+	// bpsHeader starts BPS patches
+	bpsHeader = "BPS1"
+	// bpsFooterSize is the size of the CRC32s of the source, the target and the patch ending BPS patches
+	bpsFooterSize = 12
+)
+
+// The actions of BPS patches, in the low 2 bits of their header
+const (
+	bpsSourceRead = iota
+	bpsTargetRead
+	bpsSourceCopy
+	bpsTargetCopy
+)
+
+// bpsReader reads the numbers and bytes of a BPS patch
+type bpsReader struct {
+	patch []byte
+	pos   int
+	end   int
+}
+
+// number reads a variable-length number
+func (r *bpsReader) number() (int, error) {
+	data, shift := 0, 1
+	for {
+		if r.pos >= r.end {
+			return 0, fmt.Errorf("BPS patch is truncated at byte %d", r.pos)
+		}
+		x := int(r.patch[r.pos])
+		r.pos++
+		data += (x & 0x7F) * shift
+		if x&0x80 != 0 {
+			return data, nil
+		}
+		shift <<= 7
+		data += shift
+		if shift > 1<<42 {
+			return 0, fmt.Errorf("BPS patch has an invalid number at byte %d", r.pos)
+		}
+	}
+}
+
+// bytes reads n bytes
+func (r *bpsReader) bytes(n int) ([]byte, error) {
+	if n > r.end-r.pos {
+		return nil, fmt.Errorf("BPS patch is truncated at byte %d", r.pos)
+	}
+	b := r.patch[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+// applyBPS applies a BPS patch, checking the CRC32s of the source, the target and the patch
+func applyBPS(patch, rom []byte) ([]byte, error) {
+	if len(patch) < len(bpsHeader)+bpsFooterSize {
+		return nil, fmt.Errorf("BPS patch is truncated")
+	}
+	footer := patch[len(patch)-bpsFooterSize:]
+	sourceCRC := binary.LittleEndian.Uint32(footer[0:])
+	targetCRC := binary.LittleEndian.Uint32(footer[4:])
+	patchCRC := binary.LittleEndian.Uint32(footer[8:])
+	if crc := crc32.ChecksumIEEE(patch[:len(patch)-4]); crc != patchCRC {
+		return nil, fmt.Errorf("BPS patch is corrupted, its CRC32 is %08X instead of %08X", crc, patchCRC)
+	}
+	if crc := crc32.ChecksumIEEE(rom); crc != sourceCRC {
+		return nil, fmt.Errorf("BPS patch is for a rom whose CRC32 is %08X, not %08X", sourceCRC, crc)
+	}
+
+	r := &bpsReader{patch: patch, pos: len(bpsHeader), end: len(patch) - bpsFooterSize}
+	sourceSize, err := r.number()
+	if err != nil {
+		return nil, err
+	}
+	targetSize, err := r.number()
+	if err != nil {
+		return nil, err
+	}
+	metadataSize, err := r.number()
+	if err != nil {
+		return nil, err
+	}
+	if sourceSize != len(rom) {
+		return nil, fmt.Errorf("BPS patch is for a rom of %d bytes, not %d", sourceSize, len(rom))
+	}
+	if _, err = r.bytes(metadataSize); err != nil {
+		return nil, err
+	}
+
+	var target []byte
+	sourceOffset, targetOffset := 0, 0
+	for r.pos < r.end {
+		action, err := r.number()
+		if err != nil {
+			return nil, err
+		}
+		length := action>>2 + 1
+		if len(target)+length > targetSize {
+			return nil, fmt.Errorf("BPS patch writes past the end of the target at byte %d", r.pos)
+		}
+		switch action & 0x3 {
+		case bpsSourceRead:
+			if len(target)+length > len(rom) {
+				return nil, fmt.Errorf("BPS patch reads past the end of the rom at byte %d", r.pos)
+			}
+			target = append(target, rom[len(target):len(target)+length]...)
+		case bpsTargetRead:
+			data, err := r.bytes(length)
+			if err != nil {
+				return nil, err
+			}
+			target = append(target, data...)
+		case bpsSourceCopy, bpsTargetCopy:
+			delta, err := r.number()
+			if err != nil {
+				return nil, err
+			}
+			if delta&1 != 0 {
+				delta = -(delta >> 1)
+			} else {
+				delta >>= 1
+			}
+			if action&0x3 == bpsSourceCopy {
+				sourceOffset += delta
+				if sourceOffset < 0 || sourceOffset+length > len(rom) {
+					return nil, fmt.Errorf("BPS patch copies from outside the rom at byte %d", r.pos)
+				}
+				target = append(target, rom[sourceOffset:sourceOffset+length]...)
+				sourceOffset += length
+			} else {
+				targetOffset += delta
+				if targetOffset < 0 || targetOffset >= len(target) {
+					return nil, fmt.Errorf("BPS patch copies from outside the target at byte %d", r.pos)
+				}
+				// The copy may overlap what it writes, byte by byte
+				for i := 0; i < length; i++ {
+					target = append(target, target[targetOffset])
+					targetOffset++
+				}
+			}
+		}
+	}
+
+	if len(target) != targetSize {
+		return nil, fmt.Errorf("BPS patch wrote %d bytes instead of %d", len(target), targetSize)
+	}
+	if crc := crc32.ChecksumIEEE(target); crc != targetCRC {
+		return nil, fmt.Errorf("BPS patch made a rom whose CRC32 is %08X instead of %08X", crc, targetCRC)
+	}
+	return target, nil
+}
+
+// appendNumber appends a variable-length number
+func appendNumber(b []byte, data int) []byte {
+	for {
+		x := byte(data & 0x7F)
+		data >>= 7
+		if data == 0 {
+			return append(b, x|0x80)
+		}
+		b = append(b, x)
+		data--
+	}
+}
+
+// createBPS creates a BPS patch turning source into target, reading the bytes source and target have in common
+// from the source and the others from the patch
+func createBPS(source, target []byte) []byte {
+	patch := []byte(bpsHeader)
+	patch = appendNumber(patch, len(source))
+	patch = appendNumber(patch, len(target))
+	patch = appendNumber(patch, 0)
+
+	same := func(i int) bool {
+		return i < len(source) && source[i] == target[i]
+	}
+	for offset := 0; offset < len(target); {
+		end := offset
+		for end < len(target) && same(end) == same(offset) {
+			end++
+		}
+		if same(offset) {
+			patch = appendNumber(patch, (end-offset-1)<<2|bpsSourceRead)
+		} else {
+			patch = appendNumber(patch, (end-offset-1)<<2|bpsTargetRead)
+			patch = append(patch, target[offset:end]...)
+		}
+		offset = end
+	}
+
+	footer := make([]byte, 8)
+	binary.LittleEndian.PutUint32(footer[0:], crc32.ChecksumIEEE(source))
+	binary.LittleEndian.PutUint32(footer[4:], crc32.ChecksumIEEE(target))
+	patch = append(patch, footer...)
+	crc := make([]byte, 4)
+	binary.LittleEndian.PutUint32(crc, crc32.ChecksumIEEE(patch))
+	return append(patch, crc...)
+}
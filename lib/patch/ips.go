@@ -0,0 +1,114 @@
+package patch
+
+import "fmt"
+
+const (
+	// ipsHeader starts IPS patches
+	ipsHeader = "PATCH"
+	// ipsFooter ends the records of IPS patches, it may be followed by the size to truncate the rom to
+	ipsFooter = "EOF"
+	// ipsEOFOffset is the offset that reads as the footer, records cannot start there
+	ipsEOFOffset = 0x454F46
+	// ipsMaxOffset is the first offset IPS cannot address
+	ipsMaxOffset = 0x1000000
+	// ipsMaxRecord is the largest number of bytes of a record
+	ipsMaxRecord = 0xFFFF
+)
+
+// applyIPS applies an IPS patch: records of bytes to write at an offset, or of a byte repeated
+func applyIPS(patch, rom []byte) ([]byte, error) {
+	out := append([]byte{}, rom...)
+	pos := len(ipsHeader)
+	read := func(n int) ([]byte, error) {
+		if pos+n > len(patch) {
+			return nil, fmt.Errorf("IPS patch is truncated at byte %d", len(patch))
+		}
+		b := patch[pos : pos+n]
+		pos += n
+		return b, nil
+	}
+	// write writes data at offset, growing the rom if needed
+	write := func(offset int, data []byte) {
+		if end := offset + len(data); end > len(out) {
+			out = append(out, make([]byte, end-len(out))...)
+		}
+		copy(out[offset:], data)
+	}
+
+	for {
+		b, err := read(3)
+		if err != nil {
+			return nil, err
+		}
+		if string(b) == ipsFooter {
+			break
+		}
+		offset := int(b[0])<<16 | int(b[1])<<8 | int(b[2])
+		if b, err = read(2); err != nil {
+			return nil, err
+		}
+		size := int(b[0])<<8 | int(b[1])
+		if size > 0 {
+			data, err := read(size)
+			if err != nil {
+				return nil, err
+			}
+			write(offset, data)
+			continue
+		}
+
+		// A record of size 0 repeats a byte
+		if b, err = read(3); err != nil {
+			return nil, err
+		}
+		count := int(b[0])<<8 | int(b[1])
+		data := make([]byte, count)
+		for i := range data {
+			data[i] = b[2]
+		}
+		write(offset, data)
+	}
+
+	switch len(patch) - pos {
+	case 0:
+	case 3:
+		size := int(patch[pos])<<16 | int(patch[pos+1])<<8 | int(patch[pos+2])
+		if size < len(out) {
+			out = out[:size]
+		}
+	default:
+		return nil, fmt.Errorf("IPS patch has %d unexpected bytes after its end", len(patch)-pos)
+	}
+	return out, nil
+}
+
+// createIPS creates an IPS patch writing the bytes of target that differ from source
+func createIPS(source, target []byte) ([]byte, error) {
+	if len(target) > ipsMaxOffset {
+		return nil, fmt.Errorf("IPS cannot patch roms of more than %d bytes", ipsMaxOffset)
+	}
+	patch := []byte(ipsHeader)
+	for offset := 0; offset < len(target); {
+		if offset < len(source) && source[offset] == target[offset] {
+			offset++
+			continue
+		}
+		// A record cannot start at the offset that reads as the footer, it starts a byte earlier
+		start := offset
+		if start == ipsEOFOffset {
+			start--
+		}
+		end := offset
+		for end < len(target) && end-start < ipsMaxRecord && (end >= len(source) || source[end] != target[end]) {
+			end++
+		}
+		patch = append(patch, byte(start>>16), byte(start>>8), byte(start), byte((end-start)>>8), byte(end-start))
+		patch = append(patch, target[start:end]...)
+		offset = end
+	}
+	patch = append(patch, ipsFooter...)
+	if len(target) < len(source) {
+		patch = append(patch, byte(len(target)>>16), byte(len(target)>>8), byte(len(target)))
+	}
+	return patch, nil
+}
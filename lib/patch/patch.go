@@ -0,0 +1,56 @@
+package patch
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Formats of the patches
+const (
+	IPS = "ips"
+	BPS = "bps"
+)
+
+// Apply applies an IPS or a BPS patch to a rom, the format is told by the patch's header
+func Apply(patch, rom []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(patch, []byte(ipsHeader)):
+		return applyIPS(patch, rom)
+	case bytes.HasPrefix(patch, []byte(bpsHeader)):
+		return applyBPS(patch, rom)
+	}
+	return nil, fmt.Errorf("not an IPS or BPS patch")
+}
+
+// ApplyFiles applies the patches in the given files to a rom, in order
+func ApplyFiles(filenames []string, rom []byte) ([]byte, error) {
+	for _, filename := range filenames {
+		patch, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		if rom, err = Apply(patch, rom); err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+	}
+	return rom, nil
+}
+
+// Create creates a patch turning source into target, in the given format
+func Create(format string, source, target []byte) ([]byte, error) {
+	switch format {
+	case IPS:
+		return createIPS(source, target)
+	case BPS:
+		return createBPS(source, target), nil
+	}
+	return nil, fmt.Errorf("unknown patch format %q, expected %s or %s", format, IPS, BPS)
+}
+
+// FormatOf gets the format of a patch file from its extension
+func FormatOf(filename string) string {
+	return strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+}
@@ -0,0 +1,51 @@
+package patch
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var (
+	source = []byte{0x00, 0xE0, 0x6A, 0x02, 0x6B, 0x0C, 0x12, 0x06}
+	target = []byte{0x00, 0xE0, 0x6A, 0x04, 0x6B, 0x0C, 0x12, 0x06, 0x00, 0xEE}
+)
+
+func TestIPS(t *testing.T) {
+	patch, err := Create(IPS, source, target)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("PATCH\x00\x00\x03\x00\x01\x04\x00\x00\x08\x00\x02\x00\xEEEOF"), patch)
+	rom, err := Apply(patch, source)
+	assert.Nil(t, err)
+	assert.Equal(t, target, rom)
+
+	// Shorter roms are truncated after the footer
+	patch, err = Create(IPS, target, source)
+	assert.Nil(t, err)
+	rom, err = Apply(patch, target)
+	assert.Nil(t, err)
+	assert.Equal(t, source, rom)
+
+	// A record of size 0 repeats a byte
+	rom, err = Apply([]byte("PATCH\x00\x00\x01\x00\x00\x00\x03\xFFEOF"), source)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x00, 0xFF, 0xFF, 0xFF, 0x6B, 0x0C, 0x12, 0x06}, rom)
+
+	_, err = Apply([]byte("PATCH\x00\x00\x01\x00"), source)
+	assert.NotNil(t, err)
+}
+
+func TestBPS(t *testing.T) {
+	patch, err := Create(BPS, source, target)
+	assert.Nil(t, err)
+	rom, err := Apply(patch, source)
+	assert.Nil(t, err)
+	assert.Equal(t, target, rom)
+
+	// The patch is checked against the rom it was made for
+	_, err = Apply(patch, target)
+	assert.NotNil(t, err)
+
+	patch[len(patch)-13] ^= 0xFF
+	_, err = Apply(patch, source)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,171 @@
+package profiler
+
+import (
+	"compress/gzip"
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"github.com/mlemesle/chip-go-8/lib/symbols"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// frame is a location in the ROM: an address inside the subroutine starting at entry
+type frame struct {
+	addr  uint16
+	entry uint16
+}
+
+// sample is a call stack, leaf first, and the number of times it was observed
+type sample struct {
+	frames []frame
+	count  int64
+}
+
+// Profiler samples the CHIP-8 program counter and call stack, and writes them as a pprof profile
+type Profiler struct {
+	symbols symbols.Table
+	start   time.Time
+	entry   uint16
+	started bool
+	samples map[string]*sample
+	key     strings.Builder
+}
+
+// New creates a new Profiler naming subroutines after the given symbols
+func New(table symbols.Table) *Profiler {
+	return &Profiler{
+		symbols: table,
+		start:   time.Now(),
+		samples: map[string]*sample{},
+	}
+}
+
+// BeforeCycle samples the instruction about to be executed by the emulator
+// The first sampled address is considered the ROM's entry point.
+func (p *Profiler) BeforeCycle(c *emulator.Chip8) {
+	state := c.GetState()
+	if !p.started {
+		p.entry = state.PC
+		p.started = true
+	}
+
+	// The stack holds the addresses of the CALL instructions, their target is the current subroutine
+	frames := make([]frame, 0, int(state.SP)+1)
+	addr := state.PC
+	for i := int(state.SP) - 1; i >= 0; i-- {
+		call := state.Stack[i]
+		entry := (uint16(c.ReadMemory(call))<<8 | uint16(c.ReadMemory(call+1))) & 0x0FFF
+		frames = append(frames, frame{addr: addr, entry: entry})
+		addr = call
+	}
+	frames = append(frames, frame{addr: addr, entry: p.entry})
+
+	p.key.Reset()
+	for _, f := range frames {
+		p.key.WriteByte(byte(f.addr >> 8))
+		p.key.WriteByte(byte(f.addr))
+		p.key.WriteByte(byte(f.entry >> 8))
+		p.key.WriteByte(byte(f.entry))
+	}
+	key := p.key.String()
+	if s, ok := p.samples[key]; ok {
+		s.count++
+		return
+	}
+	p.samples[key] = &sample{frames: frames, count: 1}
+}
+
+// AfterCycle does nothing, samples are taken before each cycle
+func (p *Profiler) AfterCycle(c *emulator.Chip8) {}
+
+// Write writes the gzipped pprof protobuf of the samples recorded so far
+func (p *Profiler) Write(w io.Writer) error {
+	stringTable := []string{""}
+	stringIDs := map[string]int64{"": 0}
+	str := func(s string) int64 {
+		if id, ok := stringIDs[s]; ok {
+			return id
+		}
+		stringIDs[s] = int64(len(stringTable))
+		stringTable = append(stringTable, s)
+		return stringIDs[s]
+	}
+	functionIDs := map[uint16]uint64{}
+	locationIDs := map[frame]uint64{}
+	var profile, functions, locations protoBuffer
+
+	keys := make([]string, 0, len(p.samples))
+	for key := range p.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := p.samples[key]
+		ids := make([]uint64, len(s.frames))
+		for i, f := range s.frames {
+			functionID, ok := functionIDs[f.entry]
+			if !ok {
+				functionID = uint64(len(functionIDs) + 1)
+				functionIDs[f.entry] = functionID
+				name := p.symbols.Name(f.entry, "sub_")
+				if f.entry == p.entry {
+					name = p.symbols.Name(f.entry, "main_")
+				}
+				var function protoBuffer
+				function.uint64(1, functionID)
+				function.int64(2, str(name))
+				function.int64(3, str(name))
+				function.int64(4, str("rom"))
+				function.int64(5, int64(f.entry))
+				functions.message(5, &function)
+			}
+			locationID, ok := locationIDs[f]
+			if !ok {
+				locationID = uint64(len(locationIDs) + 1)
+				locationIDs[f] = locationID
+				var line, location protoBuffer
+				line.uint64(1, functionID)
+				line.int64(2, int64(f.addr))
+				location.uint64(1, locationID)
+				location.uint64(2, 1)
+				location.uint64(3, uint64(f.addr))
+				location.message(4, &line)
+				locations.message(4, &location)
+			}
+			ids[i] = locationID
+		}
+		var sample protoBuffer
+		sample.packed(1, ids)
+		sample.packed(2, []uint64{uint64(s.count)})
+		profile.message(2, &sample)
+	}
+
+	var sampleType, mapping protoBuffer
+	sampleType.int64(1, str("instructions"))
+	sampleType.int64(2, str("count"))
+	mapping.uint64(1, 1)
+	mapping.uint64(3, 0x10000)
+	mapping.int64(5, str("rom"))
+	mapping.bool(7, true)
+	mapping.bool(8, true)
+
+	profile.message(1, &sampleType)
+	profile.message(3, &mapping)
+	profile.data = append(profile.data, locations.data...)
+	profile.data = append(profile.data, functions.data...)
+	for _, s := range stringTable {
+		profile.string(6, s)
+	}
+	profile.int64(9, p.start.UnixNano())
+	profile.int64(10, int64(time.Since(p.start)))
+	profile.message(11, &sampleType)
+	profile.int64(12, 1)
+
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(profile.data); err != nil {
+		return err
+	}
+	return gz.Close()
+}
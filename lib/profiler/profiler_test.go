@@ -0,0 +1,167 @@
+package profiler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"github.com/mlemesle/chip-go-8/lib/symbols"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// protoField is a decoded field of a protocol buffers message, a varint or the bytes of a length delimited field
+type protoField struct {
+	number int
+	value  uint64
+	bytes  []byte
+}
+
+// readVarint reads a base 128 varint at the start of data, and gets the number of bytes it took
+func readVarint(t *testing.T, data []byte) (uint64, int) {
+	var v uint64
+	for i, b := range data {
+		v |= uint64(b&0x7F) << (7 * uint(i))
+		if b < 0x80 {
+			return v, i + 1
+		}
+	}
+	t.Fatal("truncated varint")
+	return 0, 0
+}
+
+// decodeMessage decodes the varint and length delimited fields of a message, the only ones pprof uses
+func decodeMessage(t *testing.T, data []byte) []protoField {
+	var fields []protoField
+	for len(data) > 0 {
+		key, n := readVarint(t, data)
+		data = data[n:]
+		f := protoField{number: int(key >> 3)}
+		switch key & 7 {
+		case 0:
+			f.value, n = readVarint(t, data)
+			data = data[n:]
+		case 2:
+			size, n := readVarint(t, data)
+			f.bytes, data = data[n:n+int(size)], data[n+int(size):]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// decodePacked decodes a packed repeated varint field
+func decodePacked(t *testing.T, data []byte) []uint64 {
+	var values []uint64
+	for len(data) > 0 {
+		v, n := readVarint(t, data)
+		values = append(values, v)
+		data = data[n:]
+	}
+	return values
+}
+
+// decodeProfile decodes a gzipped pprof profile, its samples are keyed by their stack of "function@address" frames,
+// leaf first
+func decodeProfile(t *testing.T, data []byte) (sampleType string, samples map[string]uint64) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	assert.Nil(t, err)
+	data, err = ioutil.ReadAll(gz)
+	assert.Nil(t, err)
+
+	var strs []string
+	var sampleTypes, rawSamples [][]byte
+	functions := map[uint64]uint64{}
+	locations := map[uint64][2]uint64{}
+	for _, f := range decodeMessage(t, data) {
+		switch f.number {
+		case 1:
+			sampleTypes = append(sampleTypes, f.bytes)
+		case 2:
+			rawSamples = append(rawSamples, f.bytes)
+		case 4:
+			var id, addr, function uint64
+			for _, lf := range decodeMessage(t, f.bytes) {
+				switch lf.number {
+				case 1:
+					id = lf.value
+				case 3:
+					addr = lf.value
+				case 4:
+					function = decodeMessage(t, lf.bytes)[0].value
+				}
+			}
+			locations[id] = [2]uint64{addr, function}
+		case 5:
+			var id, name uint64
+			for _, ff := range decodeMessage(t, f.bytes) {
+				switch ff.number {
+				case 1:
+					id = ff.value
+				case 2:
+					name = ff.value
+				}
+			}
+			functions[id] = name
+		case 6:
+			strs = append(strs, string(f.bytes))
+		}
+	}
+
+	assert.Equal(t, 1, len(sampleTypes))
+	sampleType = strs[decodeMessage(t, sampleTypes[0])[0].value]
+	samples = map[string]uint64{}
+	for _, s := range rawSamples {
+		var frames []string
+		var count uint64
+		for _, f := range decodeMessage(t, s) {
+			switch f.number {
+			case 1:
+				for _, id := range decodePacked(t, f.bytes) {
+					location := locations[id]
+					frames = append(frames, fmt.Sprintf("%s@%03X", strs[functions[location[1]]], location[0]))
+				}
+			case 2:
+				count = decodePacked(t, f.bytes)[0]
+			}
+		}
+		samples[strings.Join(frames, " ")] += count
+	}
+	return sampleType, samples
+}
+
+func TestWrite(t *testing.T) {
+	c := emulator.New()
+	c.Initialize(nil)
+	assert.Nil(t, c.LoadBytes([]byte{
+		0x22, 0x06, // 200: CALL 0x206
+		0x22, 0x06, // 202: CALL 0x206
+		0x12, 0x04, // 204: JP 0x204
+		0x60, 0x01, // 206: LD V0, 0x01
+		0x00, 0xEE, // 208: RET
+	}))
+	p := New(symbols.Table{0x206: "draw"})
+	c.AddObserver(p)
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, c.EmulateCycle())
+	}
+
+	var out bytes.Buffer
+	assert.Nil(t, p.Write(&out))
+	sampleType, samples := decodeProfile(t, out.Bytes())
+	assert.Equal(t, "instructions", sampleType)
+	// Subroutines without a symbol are named after their address, the rom's entry point is main
+	assert.Equal(t, map[string]uint64{
+		"main_200@200":          1,
+		"draw@206 main_200@200": 1,
+		"draw@208 main_200@200": 1,
+		"main_200@202":          1,
+		"draw@206 main_200@202": 1,
+		"draw@208 main_200@202": 1,
+		"main_200@204":          4,
+	}, samples)
+}
@@ -0,0 +1,67 @@
+package profiler
+
+// protoBuffer is a minimal protocol buffers encoder, enough to write pprof's profile.proto
+type protoBuffer struct {
+	data []byte
+}
+
+// varint appends v as a base 128 varint
+func (b *protoBuffer) varint(v uint64) {
+	for v >= 0x80 {
+		b.data = append(b.data, byte(v)|0x80)
+		v >>= 7
+	}
+	b.data = append(b.data, byte(v))
+}
+
+// key appends the key of a field with the given wire type
+func (b *protoBuffer) key(field int, wireType uint64) {
+	b.varint(uint64(field)<<3 | wireType)
+}
+
+// uint64 appends a varint field, omitted when zero
+func (b *protoBuffer) uint64(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	b.key(field, 0)
+	b.varint(v)
+}
+
+// int64 appends a varint field, omitted when zero
+func (b *protoBuffer) int64(field int, v int64) {
+	b.uint64(field, uint64(v))
+}
+
+// bool appends a boolean field, omitted when false
+func (b *protoBuffer) bool(field int, v bool) {
+	if v {
+		b.uint64(field, 1)
+	}
+}
+
+// string appends a length delimited string field, always written as it may be a repeated value
+func (b *protoBuffer) string(field int, s string) {
+	b.key(field, 2)
+	b.varint(uint64(len(s)))
+	b.data = append(b.data, s...)
+}
+
+// packed appends a packed repeated varint field
+func (b *protoBuffer) packed(field int, values []uint64) {
+	if len(values) == 0 {
+		return
+	}
+	var inner protoBuffer
+	for _, v := range values {
+		inner.varint(v)
+	}
+	b.message(field, &inner)
+}
+
+// message appends an embedded message field
+func (b *protoBuffer) message(field int, m *protoBuffer) {
+	b.key(field, 2)
+	b.varint(uint64(len(m.data)))
+	b.data = append(b.data, m.data...)
+}
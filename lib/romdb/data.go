@@ -0,0 +1,65 @@
+// data.go is replaced by gen.go with go generate, from the files of the chip-8-database
+// (https://github.com/chip-8/chip-8-database). Until then, it holds the database's platforms only.
+
+package romdb
+
+// embeddedPlatforms are the platforms of the chip-8-database's platforms.json
+const embeddedPlatforms = `[
+  {
+    "id": "originalChip8",
+    "name": "Cosmac VIP CHIP-8",
+    "quirks": {"shift": false, "memoryIncrementByX": false, "memoryLeaveIUnchanged": false, "wrap": false, "jump": false, "vblank": true, "logic": true},
+    "defaultTickrate": 15
+  },
+  {
+    "id": "hybridVIP",
+    "name": "CHIP-8 with Cosmac VIP instructions",
+    "quirks": {"shift": false, "memoryIncrementByX": false, "memoryLeaveIUnchanged": false, "wrap": false, "jump": false, "vblank": true, "logic": true},
+    "defaultTickrate": 15
+  },
+  {
+    "id": "modernChip8",
+    "name": "Modern CHIP-8",
+    "quirks": {"shift": false, "memoryIncrementByX": false, "memoryLeaveIUnchanged": false, "wrap": false, "jump": false, "vblank": false, "logic": false},
+    "defaultTickrate": 12
+  },
+  {
+    "id": "chip8x",
+    "name": "CHIP-8X",
+    "quirks": {"shift": false, "memoryIncrementByX": false, "memoryLeaveIUnchanged": false, "wrap": false, "jump": false, "vblank": true, "logic": true},
+    "defaultTickrate": 15
+  },
+  {
+    "id": "chip48",
+    "name": "CHIP-48",
+    "quirks": {"shift": true, "memoryIncrementByX": true, "memoryLeaveIUnchanged": false, "wrap": false, "jump": true, "vblank": false, "logic": false},
+    "defaultTickrate": 30
+  },
+  {
+    "id": "superchip1",
+    "name": "SUPER-CHIP 1.0",
+    "quirks": {"shift": true, "memoryIncrementByX": true, "memoryLeaveIUnchanged": false, "wrap": false, "jump": true, "vblank": false, "logic": false},
+    "defaultTickrate": 30
+  },
+  {
+    "id": "superchip",
+    "name": "SUPER-CHIP 1.1",
+    "quirks": {"shift": true, "memoryIncrementByX": false, "memoryLeaveIUnchanged": true, "wrap": false, "jump": true, "vblank": false, "logic": false},
+    "defaultTickrate": 30
+  },
+  {
+    "id": "megachip8",
+    "name": "MEGA-CHIP",
+    "quirks": {"shift": true, "memoryIncrementByX": false, "memoryLeaveIUnchanged": true, "wrap": false, "jump": true, "vblank": false, "logic": false},
+    "defaultTickrate": 1000
+  },
+  {
+    "id": "xochip",
+    "name": "XO-CHIP",
+    "quirks": {"shift": false, "memoryIncrementByX": false, "memoryLeaveIUnchanged": false, "wrap": true, "jump": false, "vblank": false, "logic": false},
+    "defaultTickrate": 100
+  }
+]`
+
+// embeddedPrograms are the programs of the chip-8-database's programs.json, none until data.go is generated
+const embeddedPrograms = `[]`
@@ -0,0 +1,75 @@
+//go:build ignore
+// +build ignore
+
+// gen writes data.go from the platforms.json and programs.json files of the chip-8-database
+// (https://github.com/chip-8/chip-8-database), keeping the fields the emulator reads. Run it with go generate from
+// lib/romdb, after downloading the database's files next to it:
+//
+//	go generate ./lib/romdb
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/mlemesle/chip-go-8/lib/romdb"
+	"io/ioutil"
+	"os"
+)
+
+// readJSON decodes a json file into v
+func readJSON(filename string, v interface{}) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %v", filename, err)
+	}
+	return nil
+}
+
+// goString encodes v as json in a raw Go string
+// Backticks only appear in the json's strings, where they are escaped as json does with other characters.
+func goString(v interface{}) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return "`" + string(bytes.ReplaceAll(data, []byte("`"), []byte(`\u0060`))) + "`", nil
+}
+
+func main() {
+	platformsFile := flag.String("platforms", "platforms.json", "The chip-8-database's platforms.json")
+	programsFile := flag.String("programs", "programs.json", "The chip-8-database's programs.json")
+	source := flag.String("source", "https://github.com/chip-8/chip-8-database", "Where the files come from, such as the URL of the database's commit")
+	output := flag.String("o", "data.go", "File to write")
+	flag.Parse()
+
+	var platforms []romdb.Platform
+	var programs []romdb.Program
+	if err := readJSON(*platformsFile, &platforms); err != nil {
+		panic(err)
+	}
+	if err := readJSON(*programsFile, &programs); err != nil {
+		panic(err)
+	}
+	platformsJSON, err := goString(platforms)
+	if err != nil {
+		panic(err)
+	}
+	programsJSON, err := goString(programs)
+	if err != nil {
+		panic(err)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by gen.go from the chip-8-database; DO NOT EDIT.\n// Source: %s\n\npackage romdb\n\n", *source)
+	fmt.Fprintf(&out, "// embeddedPlatforms are the platforms of the chip-8-database's platforms.json\nconst embeddedPlatforms = %s\n\n", platformsJSON)
+	fmt.Fprintf(&out, "// embeddedPrograms are the %d programs of the chip-8-database's programs.json\nconst embeddedPrograms = %s\n", len(programs), programsJSON)
+	if err = ioutil.WriteFile(*output, out.Bytes(), 0644); err != nil {
+		panic(err)
+	}
+	fmt.Fprintf(os.Stderr, "%d platforms and %d programs written to %s\n", len(platforms), len(programs), *output)
+}
@@ -0,0 +1,240 @@
+package romdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"image/color"
+	"io"
+	"sort"
+	"strings"
+)
+
+//go:generate go run gen.go -platforms platforms.json -programs programs.json
+
+// bundledPrograms are the roms shipped in the rom directory, merged over the chip-8-database's programs
+const bundledPrograms = `[
+  {
+    "title": "Pong",
+    "roms": {
+      "1830eb401ba8789a477dfcf294873a5479ebcfe8": {
+        "file": "pong.c8",
+        "platforms": ["originalChip8"],
+        "keys": {"up": 1, "down": 4, "player2Up": 12, "player2Down": 13}
+      }
+    }
+  }
+]`
+
+// DefaultTickrate is the number of instructions per frame used when nothing recommends one
+const DefaultTickrate = 15
+
+// Quirks are the quirks of a platform, as in the chip-8-database
+// A nil field is not overridden when the quirks of a rom are merged with its platform's.
+type Quirks struct {
+	Shift                 *bool `json:"shift,omitempty"`
+	MemoryIncrementByX    *bool `json:"memoryIncrementByX,omitempty"`
+	MemoryLeaveIUnchanged *bool `json:"memoryLeaveIUnchanged,omitempty"`
+	Wrap                  *bool `json:"wrap,omitempty"`
+	Jump                  *bool `json:"jump,omitempty"`
+	VBlank                *bool `json:"vblank,omitempty"`
+	Logic                 *bool `json:"logic,omitempty"`
+}
+
+// Platform is an interpreter a rom can be written for, as in platforms.json
+type Platform struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	Quirks          Quirks `json:"quirks"`
+	DefaultTickrate int    `json:"defaultTickrate"`
+}
+
+// Colors are the colors a rom looks best with
+type Colors struct {
+	Pixels  []string `json:"pixels,omitempty"`
+	Buzzer  string   `json:"buzzer,omitempty"`
+	Silence string   `json:"silence,omitempty"`
+}
+
+// ROM is a file of a program, keyed by its SHA-1 in the program's roms
+type ROM struct {
+	File            string            `json:"file"`
+	Platforms       []string          `json:"platforms"`
+	QuirkyPlatforms map[string]Quirks `json:"quirkyPlatforms,omitempty"`
+	Tickrate        int               `json:"tickrate,omitempty"`
+	Keys            map[string]int    `json:"keys,omitempty"`
+	Colors          *Colors           `json:"colors,omitempty"`
+	Palette         string            `json:"palette,omitempty"`
+}
+
+// Program is a game or a demo, as in programs.json
+type Program struct {
+	Title string         `json:"title"`
+	Roms  map[string]ROM `json:"roms"`
+}
+
+// Match is what the database recommends to run a rom
+// PlatformID is the chip-8-database's ID of the platform, which is also the emulator's for the platforms it knows
+type Match struct {
+	Title      string
+	Platform   string
+	PlatformID string
+	Quirks     emulator.Quirks
+	Tickrate   int
+	Keys       map[string]int
+	Colors     []color.RGBA
+	Palette    string
+}
+
+// Database holds programs and platforms, the roms are indexed by their SHA-1
+type Database struct {
+	platforms map[string]Platform
+	programs  map[string]Program
+}
+
+// Embedded gets the database shipped with the emulator, the chip-8-database and the roms of the rom directory
+func Embedded() *Database {
+	db := &Database{
+		platforms: map[string]Platform{},
+		programs:  map[string]Program{},
+	}
+	var platforms []Platform
+	if err := json.Unmarshal([]byte(embeddedPlatforms), &platforms); err != nil {
+		panic(err)
+	}
+	for _, p := range platforms {
+		db.platforms[p.ID] = p
+	}
+	if err := db.Merge(strings.NewReader(embeddedPrograms)); err != nil {
+		panic(err)
+	}
+	if err := db.Merge(strings.NewReader(bundledPrograms)); err != nil {
+		panic(err)
+	}
+	return db
+}
+
+// Merge adds the programs of a programs.json file, overriding the roms already known
+func (db *Database) Merge(r io.Reader) error {
+	var programs []Program
+	if err := json.NewDecoder(r).Decode(&programs); err != nil {
+		return err
+	}
+	for _, p := range programs {
+		for hash := range p.Roms {
+			db.programs[strings.ToLower(hash)] = p
+		}
+	}
+	return nil
+}
+
+// Lookup finds a rom by its hexadecimal SHA-1, the bool is false if the rom is unknown
+func (db *Database) Lookup(hash string) (Match, bool, error) {
+	hash = strings.ToLower(hash)
+	program, ok := db.programs[hash]
+	if !ok {
+		return Match{}, false, nil
+	}
+	var rom ROM
+	for h, r := range program.Roms {
+		if strings.ToLower(h) == hash {
+			rom = r
+		}
+	}
+	if len(rom.Platforms) == 0 {
+		return Match{}, false, fmt.Errorf("rom %s of %q has no platform", hash, program.Title)
+	}
+
+	// The first platform is the one the rom was written for
+	platform, ok := db.platforms[rom.Platforms[0]]
+	if !ok {
+		return Match{}, false, fmt.Errorf("rom %s of %q needs unknown platform %q", hash, program.Title, rom.Platforms[0])
+	}
+	quirks := platform.Quirks.merge(rom.QuirkyPlatforms[platform.ID])
+
+	m := Match{
+		Title:      program.Title,
+		Platform:   platform.Name,
+		PlatformID: platform.ID,
+		Quirks:     quirks.resolve(),
+		Tickrate:   rom.Tickrate,
+		Keys:       rom.Keys,
+		Palette:    rom.Palette,
+	}
+	// Hybrid programs call machine-code subroutines of the COSMAC VIP
+	m.Quirks.MachineCode = platform.ID == "hybridVIP"
+	if m.Tickrate == 0 {
+		m.Tickrate = platform.DefaultTickrate
+	}
+	if m.Tickrate == 0 {
+		m.Tickrate = DefaultTickrate
+	}
+	if rom.Colors != nil {
+		for _, pixel := range rom.Colors.Pixels {
+			c, err := ParseColor(pixel)
+			if err != nil {
+				return Match{}, false, err
+			}
+			m.Colors = append(m.Colors, c)
+		}
+	}
+	return m, true, nil
+}
+
+// Platforms gets the platforms of the database, sorted by ID
+func (db *Database) Platforms() []Platform {
+	var platforms []Platform
+	for _, p := range db.platforms {
+		platforms = append(platforms, p)
+	}
+	sort.Slice(platforms, func(i, j int) bool { return platforms[i].ID < platforms[j].ID })
+	return platforms
+}
+
+// EmulatorQuirks converts the quirks of the platform to the emulator's
+func (p Platform) EmulatorQuirks() emulator.Quirks {
+	q := p.Quirks.resolve()
+	q.MachineCode = p.ID == "hybridVIP"
+	return q
+}
+
+// merge overrides q with the quirks set in override
+func (q Quirks) merge(override Quirks) Quirks {
+	for _, f := range []struct{ dst, src **bool }{
+		{&q.Shift, &override.Shift},
+		{&q.MemoryIncrementByX, &override.MemoryIncrementByX},
+		{&q.MemoryLeaveIUnchanged, &override.MemoryLeaveIUnchanged},
+		{&q.Wrap, &override.Wrap},
+		{&q.Jump, &override.Jump},
+		{&q.VBlank, &override.VBlank},
+		{&q.Logic, &override.Logic},
+	} {
+		if *f.src != nil {
+			*f.dst = *f.src
+		}
+	}
+	return q
+}
+
+// resolve converts the quirks to the emulator's
+func (q Quirks) resolve() emulator.Quirks {
+	isSet := func(b *bool) bool { return b != nil && *b }
+	return emulator.Quirks{
+		ShiftVY:       !isSet(q.Shift),
+		IncrementI:    !isSet(q.MemoryLeaveIUnchanged) && !isSet(q.MemoryIncrementByX),
+		IncrementIByX: !isSet(q.MemoryLeaveIUnchanged) && isSet(q.MemoryIncrementByX),
+		Wrap:          isSet(q.Wrap),
+		Jump:          isSet(q.Jump),
+		VBlank:        isSet(q.VBlank),
+		ResetVF:       isSet(q.Logic),
+	}
+}
+
+// ParseColor parses a #rrggbb color
+func ParseColor(s string) (color.RGBA, error) {
+	c := color.RGBA{A: 0xFF}
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return c, fmt.Errorf("invalid color %q: %v", s, err)
+	}
+	return c, nil
+}
@@ -0,0 +1,43 @@
+package romdb
+
+import (
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"github.com/stretchr/testify/assert"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	db := Embedded()
+
+	m, found, err := db.Lookup("1830EB401BA8789A477DFCF294873A5479EBCFE8")
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "Pong", m.Title)
+	assert.Equal(t, 15, m.Tickrate)
+	assert.Equal(t, emulator.Quirks{ShiftVY: true, IncrementI: true, VBlank: true, ResetVF: true}, m.Quirks)
+	assert.Equal(t, 12, m.Keys["player2Up"])
+
+	_, found, err = db.Lookup("0000000000000000000000000000000000000000")
+	assert.Nil(t, err)
+	assert.False(t, found)
+}
+
+func TestMerge(t *testing.T) {
+	db := Embedded()
+	err := db.Merge(strings.NewReader(`[{"title": "My Pong", "roms": {"1830eb401ba8789a477dfcf294873a5479ebcfe8": {
+		"platforms": ["superchip"], "tickrate": 20, "colors": {"pixels": ["#102030", "#ffcc00"]}}}}]`))
+	assert.Nil(t, err)
+
+	m, found, err := db.Lookup("1830eb401ba8789a477dfcf294873a5479ebcfe8")
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "My Pong", m.Title)
+	assert.Equal(t, "SUPER-CHIP 1.1", m.Platform)
+	assert.Equal(t, 20, m.Tickrate)
+	assert.Equal(t, emulator.Quirks{Jump: true}, m.Quirks)
+	assert.Equal(t, []color.RGBA{{0x10, 0x20, 0x30, 0xFF}, {0xFF, 0xCC, 0x00, 0xFF}}, m.Colors)
+
+	assert.NotNil(t, db.Merge(strings.NewReader(`{`)))
+}
@@ -0,0 +1,254 @@
+package romfile
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// NoOrigin is the origin of roms that do not tell where they are loaded: binaries and listings without addresses
+const NoOrigin = -1
+
+// addressSpace is the number of bytes addressable by the roms written as text
+const addressSpace = 0x10000
+
+// The formats a rom can be written in
+const (
+	formatBinary = iota
+	formatIntelHex
+	formatListing
+)
+
+// intelHexExtensions and listingExtensions are the extensions of roms written as text
+var (
+	intelHexExtensions = []string{".hex", ".ihx", ".ihex"}
+	listingExtensions  = []string{".txt", ".lst"}
+)
+
+// Decode decodes a rom written as an Intel HEX file or as a hex listing, binary roms are returned as they are
+// The format is told by the extension of name and by the content. The origin is the address of the first byte
+// of the rom, or NoOrigin when the rom does not tell it.
+func Decode(name string, data []byte) ([]byte, int, error) {
+	switch format(name, data) {
+	case formatIntelHex:
+		return decodeIntelHex(data)
+	case formatListing:
+		return decodeListing(data)
+	}
+	return data, NoOrigin, nil
+}
+
+// format tells the format of a rom
+// Files with a rom extension are binaries, other files are read as text when they only hold text.
+func format(name string, data []byte) int {
+	ext := strings.ToLower(path.Ext(name))
+	if hasExtension(ext, romExtensions) || !isText(data) {
+		return formatBinary
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(":")) {
+		return formatIntelHex
+	}
+	if hasExtension(ext, intelHexExtensions) || hasExtension(ext, listingExtensions) {
+		return formatListing
+	}
+	// Other text files are listings as long as their first word is hex
+	fields := strings.Fields(stripComment(string(data)))
+	if len(fields) > 0 {
+		word := strings.TrimSuffix(fields[0], ":")
+		if _, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimPrefix(word, "0x"), "0X"), 16, 64); err == nil {
+			return formatListing
+		}
+	}
+	return formatBinary
+}
+
+// hasExtension tells if ext is one of extensions
+func hasExtension(ext string, extensions []string) bool {
+	for _, e := range extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// isText tells if data only holds printable ASCII and whitespace
+func isText(data []byte) bool {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return false
+	}
+	for _, b := range data {
+		if (b < 0x20 || b > 0x7E) && b != '\t' && b != '\n' && b != '\r' {
+			return false
+		}
+	}
+	return true
+}
+
+// image collects the bytes of a rom written at given addresses
+type image struct {
+	memory  [addressSpace]byte
+	written [addressSpace]bool
+	start   int
+	end     int
+}
+
+// newImage creates an empty image
+func newImage() *image {
+	return &image{start: addressSpace}
+}
+
+// write writes the bytes of a line at addr
+func (img *image) write(line, addr int, data []byte) error {
+	if addr+len(data) > addressSpace {
+		return fmt.Errorf("line %d: address 0x%X is out of the 64KB address space", line, addr+len(data)-1)
+	}
+	for i, b := range data {
+		if img.written[addr+i] {
+			return fmt.Errorf("line %d: address 0x%04X is written twice", line, addr+i)
+		}
+		img.memory[addr+i] = b
+		img.written[addr+i] = true
+	}
+	if len(data) > 0 && addr < img.start {
+		img.start = addr
+	}
+	if addr+len(data) > img.end {
+		img.end = addr + len(data)
+	}
+	return nil
+}
+
+// rom gets the bytes from the lowest to the highest address written, gaps are filled with zeros
+func (img *image) rom() ([]byte, int, error) {
+	if img.start >= img.end {
+		return nil, NoOrigin, fmt.Errorf("no data found")
+	}
+	rom := make([]byte, img.end-img.start)
+	copy(rom, img.memory[img.start:img.end])
+	return rom, img.start, nil
+}
+
+// decodeIntelHex decodes an Intel HEX file, checking the checksum of each record
+func decodeIntelHex(data []byte) ([]byte, int, error) {
+	img := newImage()
+	base := 0
+	for i, text := range strings.Split(string(data), "\n") {
+		line := i + 1
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		if !strings.HasPrefix(text, ":") {
+			return nil, NoOrigin, fmt.Errorf("line %d: record does not start with ':'", line)
+		}
+		record, err := hex.DecodeString(text[1:])
+		if err != nil {
+			return nil, NoOrigin, fmt.Errorf("line %d: invalid hex in record: %v", line, err)
+		}
+		if len(record) < 5 || len(record) != int(record[0])+5 {
+			return nil, NoOrigin, fmt.Errorf("line %d: record of %d bytes does not match its length", line, len(record))
+		}
+		var sum byte
+		for _, b := range record[:len(record)-1] {
+			sum += b
+		}
+		if checksum := record[len(record)-1]; checksum != -sum {
+			return nil, NoOrigin, fmt.Errorf("line %d: bad checksum 0x%02X, expected 0x%02X", line, checksum, -sum)
+		}
+
+		addr := int(record[1])<<8 | int(record[2])
+		payload := record[4 : len(record)-1]
+		switch record[3] {
+		case 0x00:
+			if err := img.write(line, base+addr, payload); err != nil {
+				return nil, NoOrigin, err
+			}
+		case 0x01:
+			return img.rom()
+		case 0x02, 0x04:
+			if len(payload) != 2 {
+				return nil, NoOrigin, fmt.Errorf("line %d: extended address record of %d bytes", line, len(payload))
+			}
+			base = int(payload[0])<<8 | int(payload[1])
+			if record[3] == 0x02 {
+				base <<= 4
+			} else {
+				base <<= 16
+			}
+		case 0x03, 0x05:
+			// Start addresses are meaningless for CHIP-8, programs start at the platform's address
+		default:
+			return nil, NoOrigin, fmt.Errorf("line %d: unknown record type 0x%02X", line, record[3])
+		}
+	}
+	return nil, NoOrigin, fmt.Errorf("missing end of file record")
+}
+
+// decodeListing decodes a hex listing: whitespace-separated bytes or opcodes, each line optionally prefixed
+// with its address as in "0200: 6A 02 6B 0C"
+// Comments start with ';' or '#'.
+func decodeListing(data []byte) ([]byte, int, error) {
+	img := newImage()
+	addressed := false
+	addr := 0
+	for i, text := range strings.Split(string(data), "\n") {
+		line := i + 1
+		text = stripComment(text)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if colon := strings.Index(text, ":"); colon >= 0 {
+			word := strings.TrimSpace(text[:colon])
+			value, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimPrefix(word, "0x"), "0X"), 16, 16)
+			if err != nil {
+				return nil, NoOrigin, fmt.Errorf("line %d: invalid address %q", line, word)
+			}
+			if !addressed && img.end > 0 {
+				return nil, NoOrigin, fmt.Errorf("line %d: address in a listing that started without addresses", line)
+			}
+			addressed = true
+			addr = int(value)
+			text = text[colon+1:]
+		}
+
+		var values []byte
+		for _, field := range strings.Fields(text) {
+			value, err := parseHex(field)
+			if err != nil {
+				return nil, NoOrigin, fmt.Errorf("line %d: invalid hex %q", line, field)
+			}
+			values = append(values, value...)
+		}
+		if err := img.write(line, addr, values); err != nil {
+			return nil, NoOrigin, err
+		}
+		addr += len(values)
+	}
+
+	rom, origin, err := img.rom()
+	if !addressed {
+		origin = NoOrigin
+	}
+	return rom, origin, err
+}
+
+// stripComment removes the comment ending a line of a listing
+func stripComment(text string) string {
+	if i := strings.IndexAny(text, ";#"); i >= 0 {
+		return text[:i]
+	}
+	return text
+}
+
+// parseHex parses a word of a listing: an even number of hex digits, optionally prefixed with 0x
+func parseHex(word string) ([]byte, error) {
+	word = strings.TrimPrefix(strings.TrimPrefix(word, "0x"), "0X")
+	if word == "" || len(word)%2 != 0 {
+		return nil, strconv.ErrSyntax
+	}
+	return hex.DecodeString(word)
+}
@@ -0,0 +1,41 @@
+package romfile
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDecodeIntelHex(t *testing.T) {
+	data := []byte(":040200006A026B0C17\n:00000001FF\n")
+	rom, origin, err := Decode("pong.hex", data)
+	assert.Nil(t, err)
+	assert.Equal(t, 0x200, origin)
+	assert.Equal(t, []byte{0x6A, 0x02, 0x6B, 0x0C}, rom)
+
+	_, _, err = Decode("pong.hex", []byte(":040200006A026B0C16\n:00000001FF\n"))
+	assert.EqualError(t, err, "line 1: bad checksum 0x16, expected 0x17")
+
+	_, _, err = Decode("pong.hex", []byte(":040200006A026B0C17\n"))
+	assert.NotNil(t, err)
+}
+
+func TestDecodeListing(t *testing.T) {
+	rom, origin, err := Decode("pong.txt", []byte("0200: 6A 02 6B 0C ; set up\n0204: 6C3F\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, 0x200, origin)
+	assert.Equal(t, []byte{0x6A, 0x02, 0x6B, 0x0C, 0x6C, 0x3F}, rom)
+
+	rom, origin, err = Decode("-", []byte("6A 02 6B 0C\n6C 3F\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, NoOrigin, origin)
+	assert.Equal(t, []byte{0x6A, 0x02, 0x6B, 0x0C, 0x6C, 0x3F}, rom)
+
+	_, _, err = Decode("pong.txt", []byte("0200: 6A 02\n0202: 6B 0G\n"))
+	assert.EqualError(t, err, `line 2: invalid hex "0G"`)
+
+	// Binary roms are left untouched
+	rom, origin, err = Decode("pong.ch8", []byte("6A 02"))
+	assert.Nil(t, err)
+	assert.Equal(t, NoOrigin, origin)
+	assert.Equal(t, []byte("6A 02"), rom)
+}
@@ -0,0 +1,110 @@
+package romfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+)
+
+// Stdin is the name that reads the rom from the standard input
+const Stdin = "-"
+
+// romExtensions are the extensions of the files picked in an archive when no file is named
+var romExtensions = []string{".ch8", ".c8", ".sc8", ".xo8", ".c8x"}
+
+// Read reads the rom designated by name: a file, "-" for the standard input,
+// archive.zip:path/in/archive for a file of a zip archive, or archive.zip if it holds a single rom
+func Read(name string) ([]byte, error) {
+	if name == Stdin {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	archive, entry := split(name)
+	if archive == "" {
+		return ioutil.ReadFile(name)
+	}
+	data, err := ioutil.ReadFile(archive)
+	if err != nil {
+		return nil, err
+	}
+	return ReadZip(data, entry)
+}
+
+// Load reads the rom designated by name as Read does, then decodes it as Decode does
+func Load(name string) ([]byte, int, error) {
+	data, err := Read(name)
+	if err != nil {
+		return nil, NoOrigin, err
+	}
+	if _, entry := split(name); entry != "" {
+		name = entry
+	}
+	return Decode(name, data)
+}
+
+// split splits name into a zip archive and the path of an entry, the archive is empty if name is not in an archive
+func split(name string) (string, string) {
+	lower := strings.ToLower(name)
+	if strings.HasSuffix(lower, ".zip") {
+		return name, ""
+	}
+	if i := strings.LastIndex(lower, ".zip:"); i >= 0 {
+		return name[:i+len(".zip")], name[i+len(".zip:"):]
+	}
+	return "", ""
+}
+
+// ReadZip reads the entry of a zip archive, if entry is empty the archive must hold a single rom
+func ReadZip(data []byte, entry string) ([]byte, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	var found *zip.File
+	if entry != "" {
+		for _, f := range archive.File {
+			if f.Name == entry {
+				found = f
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("%s not found in archive", entry)
+		}
+	} else {
+		var roms []string
+		for _, f := range archive.File {
+			if isROM(f.Name) {
+				found = f
+				roms = append(roms, f.Name)
+			}
+		}
+		if len(roms) != 1 {
+			return nil, fmt.Errorf("archive holds %d roms, pick one with archive.zip:path: %s", len(roms), strings.Join(roms, ", "))
+		}
+	}
+
+	r, err := found.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// isROM tells if a file of an archive looks like a rom
+func isROM(name string) bool {
+	if strings.HasSuffix(name, "/") {
+		return false
+	}
+	ext := strings.ToLower(path.Ext(name))
+	for _, e := range romExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
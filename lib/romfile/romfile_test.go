@@ -0,0 +1,57 @@
+package romfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// newZip creates a zip archive holding the given files
+func newZip(t *testing.T, files map[string][]byte) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, data := range files {
+		f, err := w.Create(name)
+		assert.Nil(t, err)
+		_, err = f.Write(data)
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestReadZip(t *testing.T) {
+	data := newZip(t, map[string][]byte{
+		"games/pong.ch8":  {0x12, 0x00},
+		"games/README.md": []byte("pong"),
+	})
+	rom, err := ReadZip(data, "games/pong.ch8")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x12, 0x00}, rom)
+
+	// The only rom is picked when none is named
+	rom, err = ReadZip(data, "")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x12, 0x00}, rom)
+
+	_, err = ReadZip(data, "games/tetris.ch8")
+	assert.NotNil(t, err)
+
+	data = newZip(t, map[string][]byte{"pong.ch8": {0x12, 0x00}, "tetris.c8": {0x00, 0xE0}})
+	_, err = ReadZip(data, "")
+	assert.NotNil(t, err)
+}
+
+func TestSplit(t *testing.T) {
+	archive, entry := split("pack.zip:games/pong.ch8")
+	assert.Equal(t, "pack.zip", archive)
+	assert.Equal(t, "games/pong.ch8", entry)
+
+	archive, entry = split("PACK.ZIP")
+	assert.Equal(t, "PACK.ZIP", archive)
+	assert.Equal(t, "", entry)
+
+	archive, _ = split("rom/pong.c8")
+	assert.Equal(t, "", archive)
+}
@@ -1,6 +1,9 @@
 package screen
 
-import "github.com/mlemesle/chip-go-8/lib/emulator"
+import (
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"image/color"
+)
 
 // Chip8ScreenInterface represents the methods that needs to be implemented by the screen
 type Chip8ScreenInterface interface {
@@ -8,4 +11,11 @@ type Chip8ScreenInterface interface {
 	Destroy()
 	Draw(c *emulator.Chip8) error
 	HandleEvent(c *emulator.Chip8) bool
+	BindActions(keys map[string]int)
+	SetColors(colors []color.RGBA)
+	SetPalette(p Palette)
+	AddFilter(f FilterInterface)
+	Animating() bool
+	SetOSD(o *OSD)
+	SetControls(c *Controls)
 }
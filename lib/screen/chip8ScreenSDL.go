@@ -3,34 +3,222 @@ package screen
 import (
 	"github.com/mlemesle/chip-go-8/lib/emulator"
 	"github.com/veandco/go-sdl2/sdl"
-	"time"
+	"image"
+	"image/color"
+	"math"
+	"path/filepath"
+	"strconv"
 )
 
+// defaultKeymap maps the left of an AZERTY keyboard to the chip8's hexadecimal keypad
+var defaultKeymap = map[sdl.Keycode]int{
+	sdl.K_1: 0x1, sdl.K_2: 0x2, sdl.K_3: 0x3, sdl.K_4: 0xC,
+	sdl.K_a: 0x4, sdl.K_z: 0x5, sdl.K_e: 0x6, sdl.K_r: 0xD,
+	sdl.K_q: 0x7, sdl.K_s: 0x8, sdl.K_d: 0x9, sdl.K_f: 0xE,
+	sdl.K_w: 0xA, sdl.K_x: 0x0, sdl.K_c: 0xB, sdl.K_v: 0xF,
+}
+
+// keypad2Keymap maps the numeric keypad to CHIP-8X's second keypad, whose keys are 0x10 to 0x1F
+var keypad2Keymap = map[sdl.Keycode]int{
+	sdl.K_KP_0: 0x10, sdl.K_KP_1: 0x11, sdl.K_KP_2: 0x12, sdl.K_KP_3: 0x13,
+	sdl.K_KP_4: 0x14, sdl.K_KP_5: 0x15, sdl.K_KP_6: 0x16, sdl.K_KP_7: 0x17,
+	sdl.K_KP_8: 0x18, sdl.K_KP_9: 0x19, sdl.K_KP_DIVIDE: 0x1A, sdl.K_KP_MULTIPLY: 0x1B,
+	sdl.K_KP_MINUS: 0x1C, sdl.K_KP_PLUS: 0x1D, sdl.K_KP_ENTER: 0x1E, sdl.K_KP_PERIOD: 0x1F,
+}
+
+// Hotkeys
+const (
+	// paletteKey cycles through the built-in palettes
+	paletteKey = sdl.K_p
+	// fullscreenKey toggles between the window and fullscreen
+	fullscreenKey = sdl.K_F11
+	// statsKey shows or hides the stats on the OSD
+	statsKey = sdl.K_F1
+	// pauseKey pauses or resumes the emulation
+	pauseKey = sdl.K_F2
+	// slowerKey and fasterKey change the speed of the emulation
+	slowerKey = sdl.K_F3
+	fasterKey = sdl.K_F4
+	// debugKey shows or hides the debug overlay outlining the sprites
+	debugKey = sdl.K_F5
+	// menuOpenKey opens the menu
+	menuOpenKey = sdl.K_ESCAPE
+)
+
+// menuKeys are the keys moving in the menu
+var menuKeys = map[sdl.Keycode]menuKey{
+	sdl.K_UP:        menuUp,
+	sdl.K_DOWN:      menuDown,
+	sdl.K_LEFT:      menuLeft,
+	sdl.K_RIGHT:     menuRight,
+	sdl.K_RETURN:    menuEnter,
+	sdl.K_ESCAPE:    menuBack,
+	sdl.K_BACKSPACE: menuBack,
+}
+
+// chip8XBackgrounds are the background colors of the VP-590 color board
+var chip8XBackgrounds = []color.RGBA{
+	{0x00, 0x00, 0x80, 0xFF}, // dark blue
+	{0x00, 0x00, 0x00, 0xFF}, // black
+	{0x00, 0x80, 0x00, 0xFF}, // green
+	{0x80, 0x00, 0x00, 0xFF}, // red
+}
+
+// chip8XForegrounds are the foreground colors of the VP-590 color board
+var chip8XForegrounds = []color.RGBA{
+	{0x00, 0x00, 0x00, 0xFF}, // black
+	{0xFF, 0x00, 0x00, 0xFF}, // red
+	{0x00, 0x00, 0xFF, 0xFF}, // blue
+	{0xFF, 0x00, 0xFF, 0xFF}, // violet
+	{0x00, 0xFF, 0x00, 0xFF}, // green
+	{0xFF, 0xFF, 0x00, 0xFF}, // yellow
+	{0x00, 0xFF, 0xFF, 0xFF}, // aqua
+	{0xFF, 0xFF, 0xFF, 0xFF}, // white
+}
+
+// actionKeys are the keyboard keys bound to the actions of the chip-8-database's keys
+var actionKeys = map[string]sdl.Keycode{
+	"up":           sdl.K_UP,
+	"down":         sdl.K_DOWN,
+	"left":         sdl.K_LEFT,
+	"right":        sdl.K_RIGHT,
+	"a":            sdl.K_SPACE,
+	"b":            sdl.K_RETURN,
+	"player2Up":    sdl.K_i,
+	"player2Down":  sdl.K_k,
+	"player2Left":  sdl.K_j,
+	"player2Right": sdl.K_l,
+}
+
 // Chip8ScreenSDL represents a display for the chip8, it uses the SDL library
 type Chip8ScreenSDL struct {
-	durationBetweenFrame time.Duration
-	lastDraw             time.Time
-	window               *sdl.Window
-	renderer             *sdl.Renderer
-	w                    int32
-	h                    int32
-	ratio                int32
+	window   *sdl.Window
+	renderer *sdl.Renderer
+	w        int32
+	h        int32
+	ratio    int32
+	// integer scales the display by whole numbers only, aspect is the width of a pixel divided by its height
+	integer    bool
+	aspect     float64
+	fullscreen bool
+	keymap     map[sdl.Keycode]int
+	palette    Palette
+	filters    []FilterInterface
+	frame      *image.RGBA
+	texture    *sdl.Texture
+	textureW   int
+	textureH   int
+	osd        *OSD
+	controls   *Controls
+	// overlay is the image the OSD is drawn in, uploaded to overlayTexture
+	overlay        *image.RGBA
+	overlayTexture *sdl.Texture
+	menu           Menu
+	menuOptions    MenuOptions
+	// romDir is the directory the rom browser shows, pausedBeforeMenu is the pause to go back to once the menu closes
+	romDir           string
+	pausedBeforeMenu bool
+	quit             bool
+	// debug outlines the sprites drawn and their collisions, and describes the sprite under the mouse in hover
+	debug   bool
+	mouse   image.Point
+	mouseIn bool
+	hover   []string
+	hoverAt image.Point
 }
 
 // NewChip8ScreenSDL creates a new non-initialized Chip8ScreenSDL
 func NewChip8ScreenSDL(w, h, ratio int32) *Chip8ScreenSDL {
-	return &Chip8ScreenSDL{
-		durationBetweenFrame: time.Duration(time.Second / 60),
-		w:                    w,
-		h:                    h,
-		ratio:                ratio,
+	c8s := &Chip8ScreenSDL{
+		w:        w,
+		h:        h,
+		ratio:    ratio,
+		aspect:   1,
+		palette:  PaletteClassic,
+		osd:      NewOSD(),
+		controls: NewControls(),
+	}
+	c8s.BindActions(nil)
+	return c8s
+}
+
+// BindActions binds the arrows, space, return and IJKL to the chip8's keys of the given actions,
+// named as in the chip-8-database, instead of the actions bound before
+func (c8s *Chip8ScreenSDL) BindActions(keys map[string]int) {
+	c8s.keymap = map[sdl.Keycode]int{}
+	for keycode, key := range defaultKeymap {
+		c8s.keymap[keycode] = key
 	}
+	for keycode, key := range keypad2Keymap {
+		c8s.keymap[keycode] = key
+	}
+	for action, key := range keys {
+		if keycode, ok := actionKeys[action]; ok {
+			c8s.keymap[keycode] = key
+		}
+	}
+}
+
+// SetColors sets the background color and the color of the pixels that are on, the other colors of the palette are kept
+func (c8s *Chip8ScreenSDL) SetColors(colors []color.RGBA) {
+	c8s.palette = c8s.palette.withColors(colors)
+}
+
+// SetPalette sets the colors of the display
+func (c8s *Chip8ScreenSDL) SetPalette(p Palette) {
+	c8s.palette = p
+}
+
+// SetOSD sets the OSD drawn over the game
+func (c8s *Chip8ScreenSDL) SetOSD(o *OSD) {
+	c8s.osd = o
+}
+
+// SetControls sets the controls changed by the hotkeys
+func (c8s *Chip8ScreenSDL) SetControls(c *Controls) {
+	c8s.controls = c
+}
+
+// SetMenu sets the rom directory and the quirks profiles offered by the menu
+func (c8s *Chip8ScreenSDL) SetMenu(options MenuOptions) {
+	c8s.menuOptions = options
+	c8s.romDir = options.ROMDir
+}
+
+// SetSpriteDebug shows or hides the debug overlay outlining the sprites drawn during the last frame
+func (c8s *Chip8ScreenSDL) SetSpriteDebug(debug bool) {
+	c8s.debug = debug
+}
+
+// SetScaling sets how the display is scaled to the window: by whole numbers only with integer, and with pixels
+// aspect times as wide as they are high
+func (c8s *Chip8ScreenSDL) SetScaling(integer bool, aspect float64) {
+	c8s.integer = integer
+	c8s.aspect = aspect
+}
+
+// SetFullscreen switches between the window and fullscreen, at the desktop's resolution
+func (c8s *Chip8ScreenSDL) SetFullscreen(fullscreen bool) error {
+	if c8s.window != nil {
+		var flags uint32
+		if fullscreen {
+			flags = sdl.WINDOW_FULLSCREEN_DESKTOP
+		}
+		if err := c8s.window.SetFullscreen(flags); err != nil {
+			return err
+		}
+	}
+	c8s.fullscreen = fullscreen
+	return nil
 }
 
 // Init initializes the given Chip8ScreenSDL
 func (c8s *Chip8ScreenSDL) Init() error {
 	sdl.Init(sdl.INIT_EVERYTHING)
-	window, err := sdl.CreateWindow("Chip 8 emulator", sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED, c8s.w*c8s.ratio, c8s.h*c8s.ratio, sdl.WINDOW_SHOWN)
+	// Frames are scaled up from the emulator's resolution, pixels must stay sharp
+	sdl.SetHint(sdl.HINT_RENDER_SCALE_QUALITY, "0")
+	window, err := sdl.CreateWindow("Chip 8 emulator", sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		c8s.width(c8s.ratio), c8s.h*c8s.ratio, sdl.WINDOW_SHOWN|sdl.WINDOW_RESIZABLE)
 	if err != nil {
 		return err
 	}
@@ -40,130 +228,362 @@ func (c8s *Chip8ScreenSDL) Init() error {
 	}
 	c8s.window = window
 	c8s.renderer = renderer
-	c8s.lastDraw = time.Now()
-	return nil
+	c8s.window.SetMinimumSize(c8s.width(1), c8s.h)
+	return c8s.SetFullscreen(c8s.fullscreen)
+}
+
+// width gets the width of the display when its pixels are scale high, with their aspect
+func (c8s *Chip8ScreenSDL) width(scale int32) int32 {
+	return int32(math.Round(float64(c8s.w*scale) * c8s.aspect))
 }
 
 // Destroy cleans the struct and free the memory
 func (c8s *Chip8ScreenSDL) Destroy() {
+	if c8s.texture != nil {
+		c8s.texture.Destroy()
+	}
+	if c8s.overlayTexture != nil {
+		c8s.overlayTexture.Destroy()
+	}
 	c8s.renderer.Destroy()
 	c8s.window.Destroy()
 }
 
+// AddFilter adds a filter post-processing the frames, after the filters already added
+func (c8s *Chip8ScreenSDL) AddFilter(f FilterInterface) {
+	c8s.filters = append(c8s.filters, f)
+}
+
+// Animating tells if a filter, the OSD or the debug overlay changes the display even when the Chip8 draws nothing, it
+// must then be drawn every frame
+func (c8s *Chip8ScreenSDL) Animating() bool {
+	return animating(c8s.filters) || c8s.osd.visible(c8s.controls) || c8s.menu.isOpen() || c8s.debug
+}
+
 // Draw displays the gfx of the Chip8 on the screen
+// The filtered frame is uploaded to a texture, which SDL scales to the largest area of the window with the aspect
+// of the display. When the resolution of the Chip8 changes, the window keeps its size.
 func (c8s *Chip8ScreenSDL) Draw(c *emulator.Chip8) error {
-	if elapsed := time.Since(c8s.lastDraw); elapsed < c8s.durationBetweenFrame {
-		timeToWait := time.Until(c8s.lastDraw.Add(c8s.durationBetweenFrame - elapsed))
-		time.Sleep(timeToWait)
+	c.RecordSprites(c8s.debug)
+	if w, h := c.GetResolution(); int32(w) != c8s.w || int32(h) != c8s.h {
+		c8s.w, c8s.h = int32(w), int32(h)
+		c8s.window.SetMinimumSize(c8s.width(1), c8s.h)
 	}
 
-	c8s.renderer.SetDrawColor(0, 0, 0, 255)
+	f := renderFrame(c, c8s.palette, c8s.frame)
+	c8s.frame = f.Image
+	f = applyFilters(c8s.filters, f)
+
+	if w, h := f.Image.Rect.Dx(), f.Image.Rect.Dy(); c8s.texture == nil || w != c8s.textureW || h != c8s.textureH {
+		if c8s.texture != nil {
+			c8s.texture.Destroy()
+		}
+		texture, err := c8s.renderer.CreateTexture(sdl.PIXELFORMAT_RGBA32, sdl.TEXTUREACCESS_STREAMING, int32(w), int32(h))
+		if err != nil {
+			return err
+		}
+		c8s.texture, c8s.textureW, c8s.textureH = texture, w, h
+	}
+	if err := c8s.texture.Update(nil, f.Image.Pix, f.Image.Stride); err != nil {
+		return err
+	}
+	outW, outH, err := c8s.renderer.GetOutputSize()
+	if err != nil {
+		return err
+	}
+	dst := viewport(int(outW), int(outH), int(c8s.w), int(c8s.h), c8s.aspect, c8s.integer)
+	c8s.renderer.SetDrawColor(0, 0, 0, 0xFF)
 	if err := c8s.renderer.Clear(); err != nil {
 		return err
 	}
+	rect := sdlRect(dst)
+	if err := c8s.renderer.Copy(c8s.texture, nil, &rect); err != nil {
+		return err
+	}
+	c8s.hover = nil
+	if c8s.debug {
+		if err := c8s.drawSprites(c, dst, int(outW), int(outH)); err != nil {
+			return err
+		}
+	}
+	if c8s.osd.visible(c8s.controls) || c8s.menu.isOpen() || len(c8s.hover) > 0 {
+		if err := c8s.drawOSD(int(outW), int(outH)); err != nil {
+			return err
+		}
+	}
+	c8s.renderer.Present()
+	c.SetDraw(false)
+	return nil
+}
 
-	var x, y int32
-	for y = 0; y < c8s.h; y++ {
-		for x = 0; x < c8s.w; x++ {
-			if c.GetGFX()[x+y*64] == 0 {
-				continue
+// sdlRect converts a rectangle of the window to SDL's
+func sdlRect(r image.Rectangle) sdl.Rect {
+	return sdl.Rect{X: int32(r.Min.X), Y: int32(r.Min.Y), W: int32(r.Dx()), H: int32(r.Dy())}
+}
+
+// drawSprites outlines the sprites drawn during the last frame over the display drawn in dst, each in a color, and
+// fills the pixels they collided with
+// The sprite under the mouse is described in hover, drawn with the OSD.
+func (c8s *Chip8ScreenSDL) drawSprites(c *emulator.Chip8, dst image.Rectangle, outW, outH int) error {
+	w, h := int(c8s.w), int(c8s.h)
+	sprites := c.Sprites()
+	if err := c8s.renderer.SetDrawBlendMode(sdl.BLENDMODE_BLEND); err != nil {
+		return err
+	}
+	for i, s := range sprites {
+		col := spriteColors[i%len(spriteColors)]
+		c8s.renderer.SetDrawColor(col.R, col.G, col.B, col.A)
+		for _, r := range spriteRects(s, w, h) {
+			rect := sdlRect(displayToWindow(r, dst, w, h))
+			if err := c8s.renderer.DrawRect(&rect); err != nil {
+				return err
 			}
-			c8s.renderer.SetDrawColor(255, 255, 255, 255)
-			if err := c8s.renderer.FillRect(&sdl.Rect{
-				X: int32(x) * c8s.ratio,
-				Y: int32(y) * c8s.ratio,
-				W: c8s.ratio,
-				H: c8s.ratio,
-			}); err != nil {
+		}
+	}
+	c8s.renderer.SetDrawColor(collisionColor.R, collisionColor.G, collisionColor.B, collisionColor.A)
+	for _, s := range sprites {
+		for _, i := range s.Collisions {
+			rect := sdlRect(displayToWindow(image.Rect(i%w, i/w, i%w+1, i/w+1), dst, w, h))
+			if err := c8s.renderer.FillRect(&rect); err != nil {
 				return err
 			}
 		}
 	}
 
-	c8s.renderer.Present()
-	c.SetDraw(false)
-	c8s.lastDraw = time.Now()
+	// The mouse moves in the window's coordinates, which differ from the renderer's on high DPI displays
+	winW, winH := c8s.window.GetSize()
+	if !c8s.mouseIn || winW == 0 || winH == 0 {
+		return nil
+	}
+	p := image.Pt(c8s.mouse.X*outW/int(winW), c8s.mouse.Y*outH/int(winH))
+	if pixel, ok := windowToDisplay(p, dst, w, h); ok {
+		if s, ok := spriteAt(sprites, pixel, w, h); ok {
+			c8s.hover, c8s.hoverAt = describeSprite(s), p
+		}
+	}
 	return nil
 }
 
-// HandleEvent processes the user's inputs
+// drawOSD draws the OSD and the menu over the window, their text is scaled to the window's height as the display
+func (c8s *Chip8ScreenSDL) drawOSD(outW, outH int) error {
+	scale := outH / (osdRows * (glyphH + glyphSpace*2))
+	if scale < 1 {
+		scale = 1
+	}
+	overlay := reuse(c8s.overlay, outW/scale, outH/scale)
+	if overlay != c8s.overlay || c8s.overlayTexture == nil {
+		if c8s.overlayTexture != nil {
+			c8s.overlayTexture.Destroy()
+		}
+		texture, err := c8s.renderer.CreateTexture(sdl.PIXELFORMAT_RGBA32, sdl.TEXTUREACCESS_STREAMING,
+			int32(overlay.Rect.Dx()), int32(overlay.Rect.Dy()))
+		if err != nil {
+			return err
+		}
+		if err = texture.SetBlendMode(sdl.BLENDMODE_BLEND); err != nil {
+			return err
+		}
+		c8s.overlay, c8s.overlayTexture = overlay, texture
+	}
+
+	for i := range overlay.Pix {
+		overlay.Pix[i] = 0
+	}
+	c8s.osd.draw(overlay, c8s.controls)
+	if len(c8s.hover) > 0 {
+		drawTooltip(overlay, c8s.hoverAt.Div(scale), c8s.hover)
+	}
+	if c8s.menu.isOpen() {
+		c8s.menu.draw(overlay)
+	}
+	if err := c8s.overlayTexture.Update(nil, overlay.Pix, overlay.Stride); err != nil {
+		return err
+	}
+	rect := sdl.Rect{W: int32(overlay.Rect.Dx() * scale), H: int32(overlay.Rect.Dy() * scale)}
+	return c8s.renderer.Copy(c8s.overlayTexture, nil, &rect)
+}
+
+// openMenu opens the menu and pauses the emulation, the keys of the Chip8 are released
+func (c8s *Chip8ScreenSDL) openMenu(c *emulator.Chip8) {
+	for _, key := range c8s.keymap {
+		c.SetKeyUp(key)
+	}
+	c8s.pausedBeforeMenu = c8s.controls.Paused
+	c8s.controls.Paused = true
+	c8s.menu.push(c8s.mainPage(c))
+}
+
+// mainPage creates the first page of the menu
+func (c8s *Chip8ScreenSDL) mainPage(c *emulator.Chip8) *menuPage {
+	p := &menuPage{title: "Chip 8 emulator"}
+	p.items = append(p.items,
+		menuItem{label: "Resume", activate: c8s.menu.close},
+		menuItem{label: "Roms", activate: func() { c8s.menu.push(c8s.browserPage()) }},
+		menuItem{
+			label:  "Palette",
+			value:  func() string { return c8s.palette.Name },
+			change: func(delta int) { c8s.palette = nextPalette(c8s.palette, delta) },
+		},
+		menuItem{
+			label: "Speed",
+			value: func() string { return "x" + strconv.FormatFloat(c8s.controls.Speed, 'g', -1, 64) },
+			change: func(delta int) {
+				if delta < 0 {
+					c8s.controls.Slower()
+				} else {
+					c8s.controls.Faster()
+				}
+			},
+		},
+	)
+	if profiles := c8s.menuOptions.Profiles; len(profiles) > 0 {
+		// The quirks of the rom may match none of the profiles, the first change then picks the first profile
+		current := func() int {
+			for i, profile := range profiles {
+				if profile.Quirks == c.GetQuirks() {
+					return i
+				}
+			}
+			return -1
+		}
+		p.items = append(p.items, menuItem{
+			label: "Quirks",
+			value: func() string {
+				if i := current(); i >= 0 {
+					return profiles[i].Name
+				}
+				return "Custom"
+			},
+			change: func(delta int) {
+				i := current()
+				if i < 0 {
+					i = 0
+				} else {
+					i = ((i+delta)%len(profiles) + len(profiles)) % len(profiles)
+				}
+				c.SetQuirks(profiles[i].Quirks)
+			},
+		})
+	}
+	p.items = append(p.items,
+		menuItem{label: "Reset", activate: func() {
+			c8s.controls.Reset = true
+			c8s.menu.close()
+		}},
+		menuItem{label: "Quit", activate: func() { c8s.quit = true }},
+	)
+	return p
+}
+
+// browserPage creates a page of the rom browser showing romDir
+func (c8s *Chip8ScreenSDL) browserPage() *menuPage {
+	if dir, err := filepath.Abs(c8s.romDir); err == nil {
+		c8s.romDir = dir
+	}
+	load := func(path string) {
+		c8s.controls.Load = path
+		c8s.menu.close()
+	}
+	open := func(dir string) {
+		c8s.romDir = dir
+		c8s.menu.key(menuBack)
+		c8s.menu.push(c8s.browserPage())
+	}
+	return browserPage(c8s.romDir, c8s.menuOptions.Describe, load, open)
+}
+
+// hotkey runs the action of a hotkey pressed, it tells if the key is a hotkey
+func (c8s *Chip8ScreenSDL) hotkey(key sdl.Keycode) bool {
+	switch key {
+	case paletteKey:
+		c8s.palette = nextPalette(c8s.palette, 1)
+		c8s.osd.Message("Palette %s", c8s.palette.Name)
+	case fullscreenKey:
+		// The window stays as it was if the display refuses fullscreen
+		if err := c8s.SetFullscreen(!c8s.fullscreen); err != nil {
+			c8s.osd.Message("No fullscreen: %v", err)
+		}
+	case statsKey:
+		c8s.osd.ToggleStats()
+	case pauseKey:
+		c8s.controls.TogglePause()
+		if !c8s.controls.Paused {
+			c8s.osd.Message("Resumed")
+		}
+	case slowerKey:
+		c8s.controls.Slower()
+		c8s.osd.Message("Speed x%g", c8s.controls.Speed)
+	case fasterKey:
+		c8s.controls.Faster()
+		c8s.osd.Message("Speed x%g", c8s.controls.Speed)
+	case debugKey:
+		c8s.debug = !c8s.debug
+		if c8s.debug {
+			c8s.osd.Message("Sprite debug on")
+		} else {
+			c8s.osd.Message("Sprite debug off")
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// HandleEvent processes the user's inputs, the files dropped on the window, and the mouse for the debug overlay
 func (c8s *Chip8ScreenSDL) HandleEvent(c *emulator.Chip8) bool {
 	// Poll for Quit and Keyboard events
 	for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
 		switch et := event.(type) {
 		case *sdl.QuitEvent:
 			return true
+		case *sdl.DropEvent:
+			// A rom dropped on the window runs at once, closing the menu
+			if et.Type == sdl.DROPFILE {
+				c8s.controls.Load = et.File
+				if c8s.menu.isOpen() {
+					c8s.menu.close()
+					c8s.controls.Paused = c8s.pausedBeforeMenu
+				}
+			}
+		case *sdl.MouseMotionEvent:
+			c8s.mouse, c8s.mouseIn = image.Pt(int(et.X), int(et.Y)), true
+		case *sdl.WindowEvent:
+			if et.Event == sdl.WINDOWEVENT_SIZE_CHANGED || et.Event == sdl.WINDOWEVENT_EXPOSED {
+				c.SetDraw(true)
+			}
+			if et.Event == sdl.WINDOWEVENT_LEAVE {
+				c8s.mouseIn = false
+			}
 		case *sdl.KeyboardEvent:
-			if et.Type == sdl.KEYUP {
-				switch et.Keysym.Sym {
-				case sdl.K_1:
-					c.SetKeyUp(0x1)
-				case sdl.K_2:
-					c.SetKeyUp(0x2)
-				case sdl.K_3:
-					c.SetKeyUp(0x3)
-				case sdl.K_4:
-					c.SetKeyUp(0xC)
-				case sdl.K_a:
-					c.SetKeyUp(0x4)
-				case sdl.K_z:
-					c.SetKeyUp(0x5)
-				case sdl.K_e:
-					c.SetKeyUp(0x6)
-				case sdl.K_r:
-					c.SetKeyUp(0xD)
-				case sdl.K_q:
-					c.SetKeyUp(0x7)
-				case sdl.K_s:
-					c.SetKeyUp(0x8)
-				case sdl.K_d:
-					c.SetKeyUp(0x9)
-				case sdl.K_f:
-					c.SetKeyUp(0xE)
-				case sdl.K_w:
-					c.SetKeyUp(0xA)
-				case sdl.K_x:
-					c.SetKeyUp(0x0)
-				case sdl.K_c:
-					c.SetKeyUp(0xB)
-				case sdl.K_v:
-					c.SetKeyUp(0xF)
+			if c8s.menu.isOpen() {
+				if k, ok := menuKeys[et.Keysym.Sym]; ok && et.Type == sdl.KEYDOWN {
+					c8s.menu.key(k)
+					if !c8s.menu.isOpen() {
+						c8s.controls.Paused = c8s.pausedBeforeMenu
+					}
 				}
-			} else if et.Type == sdl.KEYDOWN {
-				switch et.Keysym.Sym {
-				case sdl.K_1:
-					c.SetKeyDown(0x1)
-				case sdl.K_2:
-					c.SetKeyDown(0x2)
-				case sdl.K_3:
-					c.SetKeyDown(0x3)
-				case sdl.K_4:
-					c.SetKeyDown(0xC)
-				case sdl.K_a:
-					c.SetKeyDown(0x4)
-				case sdl.K_z:
-					c.SetKeyDown(0x5)
-				case sdl.K_e:
-					c.SetKeyDown(0x6)
-				case sdl.K_r:
-					c.SetKeyDown(0xD)
-				case sdl.K_q:
-					c.SetKeyDown(0x7)
-				case sdl.K_s:
-					c.SetKeyDown(0x8)
-				case sdl.K_d:
-					c.SetKeyDown(0x9)
-				case sdl.K_f:
-					c.SetKeyDown(0xE)
-				case sdl.K_w:
-					c.SetKeyDown(0xA)
-				case sdl.K_x:
-					c.SetKeyDown(0x0)
-				case sdl.K_c:
-					c.SetKeyDown(0xB)
-				case sdl.K_v:
-					c.SetKeyDown(0xF)
+				if c8s.quit {
+					return true
 				}
+				continue
+			}
+			if et.Type == sdl.KEYDOWN && et.Repeat == 0 && et.Keysym.Sym == menuOpenKey {
+				c8s.openMenu(c)
+				continue
+			}
+			// Hotkeys act once when pressed, not while they are held
+			if et.Type == sdl.KEYDOWN && et.Repeat == 0 && c8s.hotkey(et.Keysym.Sym) {
+				c.SetDraw(true)
+				continue
+			}
+			key, ok := c8s.keymap[et.Keysym.Sym]
+			if !ok {
+				continue
+			}
+			if et.Type == sdl.KEYUP {
+				c.SetKeyUp(key)
+			} else if et.Type == sdl.KEYDOWN {
+				c.SetKeyDown(key)
 			}
 		}
 	}
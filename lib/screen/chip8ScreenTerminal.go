@@ -0,0 +1,394 @@
+package screen
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+// keyHold is how long a key stays pressed after the terminal sent it
+// Terminals only send keys when they are pressed and auto-repeated, never when they are released.
+const keyHold = 250 * time.Millisecond
+
+// escapeDelay is how long an escape waits for the rest of its sequence before it is taken as the Escape key
+// Terminals send escape sequences at once, but they may be split between reads, over SSH for instance.
+const escapeDelay = 100 * time.Millisecond
+
+// Escape sequences of the keys and of the terminal's controls
+const (
+	keyCtrlC     = "\x03"
+	keyEscape    = "\x1b"
+	keyUp        = "\x1b[A"
+	keyDown      = "\x1b[B"
+	keyRight     = "\x1b[C"
+	keyLeft      = "\x1b[D"
+	enterScreen  = "\x1b[?1049h\x1b[?25l\x1b[2J"
+	leaveScreen  = "\x1b[0m\x1b[?25h\x1b[?1049l"
+	clearScreen  = "\x1b[2J"
+	upperHalfBox = "▀"
+)
+
+// Hotkeys of the terminal, as those of the window
+const (
+	terminalPaletteKey = "p"
+	terminalStatsKey   = "o"
+	terminalPauseKey   = "h"
+	terminalSlowerKey  = "-"
+	terminalFasterKey  = "+"
+)
+
+// terminalKeymap maps the left of an AZERTY keyboard to the chip8's hexadecimal keypad, as defaultKeymap
+var terminalKeymap = map[string]int{
+	"1": 0x1, "2": 0x2, "3": 0x3, "4": 0xC,
+	"a": 0x4, "z": 0x5, "e": 0x6, "r": 0xD,
+	"q": 0x7, "s": 0x8, "d": 0x9, "f": 0xE,
+	"w": 0xA, "x": 0x0, "c": 0xB, "v": 0xF,
+}
+
+// terminalActionKeys are the keys bound to the actions of the chip-8-database's keys, as actionKeys
+var terminalActionKeys = map[string]string{
+	"up":           keyUp,
+	"down":         keyDown,
+	"left":         keyLeft,
+	"right":        keyRight,
+	"a":            " ",
+	"b":            "\r",
+	"player2Up":    "i",
+	"player2Down":  "k",
+	"player2Left":  "j",
+	"player2Right": "l",
+}
+
+// cell is a character of the terminal, drawing 2 pixels above each other with an upper half block
+type cell struct {
+	top    color.RGBA
+	bottom color.RGBA
+}
+
+// Terminal represents a display for the chip8 in a terminal supporting truecolor, over SSH for instance
+type Terminal struct {
+	in        *os.File
+	out       io.Writer
+	state     string
+	input     chan string
+	resized   chan os.Signal
+	pending   string
+	pendingAt time.Time
+	keymap    map[string]int
+	pressed   map[int]time.Time
+	palette   Palette
+	filters   []FilterInterface
+	frame     *image.RGBA
+	osd       *OSD
+	controls  *Controls
+	w         int
+	h         int
+	cells     []cell
+	redraw    bool
+	clear     bool
+}
+
+// NewTerminal creates a new non-initialized Terminal, drawing on the standard output and reading the standard input
+func NewTerminal() *Terminal {
+	t := &Terminal{
+		in:       os.Stdin,
+		out:      os.Stdout,
+		input:    make(chan string, 64),
+		resized:  make(chan os.Signal, 1),
+		pressed:  map[int]time.Time{},
+		palette:  PaletteClassic,
+		osd:      NewOSD(),
+		controls: NewControls(),
+	}
+	t.BindActions(nil)
+	return t
+}
+
+// BindActions binds the arrows, space, return and IJKL to the chip8's keys of the given actions,
+// named as in the chip-8-database, instead of the actions bound before
+func (t *Terminal) BindActions(keys map[string]int) {
+	t.keymap = map[string]int{}
+	for key, value := range terminalKeymap {
+		t.keymap[key] = value
+	}
+	for action, key := range keys {
+		if sequence, ok := terminalActionKeys[action]; ok {
+			t.keymap[sequence] = key
+		}
+	}
+}
+
+// SetColors sets the background color and the color of the pixels that are on, the other colors of the palette are kept
+func (t *Terminal) SetColors(colors []color.RGBA) {
+	t.SetPalette(t.palette.withColors(colors))
+}
+
+// SetPalette sets the colors of the display
+func (t *Terminal) SetPalette(p Palette) {
+	t.palette = p
+	t.redraw = true
+}
+
+// SetOSD sets the OSD drawn over the game
+func (t *Terminal) SetOSD(o *OSD) {
+	t.osd = o
+}
+
+// SetControls sets the controls changed by the hotkeys
+func (t *Terminal) SetControls(c *Controls) {
+	t.controls = c
+}
+
+// Init switches the terminal to raw mode and to its alternate screen, and starts reading the keyboard
+func (t *Terminal) Init() error {
+	state, err := t.stty("-g")
+	if err != nil {
+		return fmt.Errorf("the terminal frontend needs a terminal: %v", err)
+	}
+	t.state = strings.TrimSpace(state)
+	if _, err = t.stty("raw", "-echo"); err != nil {
+		return err
+	}
+	if _, err = io.WriteString(t.out, enterScreen); err != nil {
+		return err
+	}
+	notifyResize(t.resized)
+
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := t.in.Read(buf)
+			if err != nil {
+				close(t.input)
+				return
+			}
+			t.input <- string(buf[:n])
+		}
+	}()
+	return nil
+}
+
+// Destroy gives the terminal back as it was
+func (t *Terminal) Destroy() {
+	signal.Stop(t.resized)
+	io.WriteString(t.out, leaveScreen)
+	t.stty(t.state)
+}
+
+// stty runs stty on the terminal
+func (t *Terminal) stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = t.in
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// AddFilter adds a filter post-processing the frames, after the filters already added
+func (t *Terminal) AddFilter(f FilterInterface) {
+	t.filters = append(t.filters, f)
+}
+
+// Animating tells if a filter or the OSD changes the display even when the Chip8 draws nothing, it must then be drawn
+// every frame
+func (t *Terminal) Animating() bool {
+	return animating(t.filters) || t.osd.visible(t.controls)
+}
+
+// Draw displays the gfx of the Chip8 in the terminal, only the cells that changed since the last frame are drawn
+func (t *Terminal) Draw(c *emulator.Chip8) error {
+	f := renderFrame(c, t.palette, t.frame)
+	t.frame = f.Image
+	img := applyFilters(t.filters, f).Image
+	if t.osd.visible(t.controls) {
+		// The OSD is drawn with a pixel of its font per pixel of the display, in the frame
+		t.osd.draw(img, t.controls)
+	}
+
+	var buf bytes.Buffer
+	if w, h := img.Rect.Dx(), img.Rect.Dy(); w != t.w || h != t.h {
+		t.w, t.h = w, h
+		t.cells = make([]cell, w*((h+1)/2))
+		t.clear = true
+	}
+	if t.clear {
+		buf.WriteString(clearScreen)
+		t.redraw, t.clear = true, false
+	}
+	pixel := func(x, y int) color.RGBA {
+		if y >= t.h {
+			return f.Background
+		}
+		return img.RGBAAt(img.Rect.Min.X+x, img.Rect.Min.Y+y)
+	}
+
+	// The cursor and the colors are only set when they differ from where the last cell left them
+	cursorX, cursorY := -1, -1
+	var fg, back color.RGBA
+	colored := false
+	for row := 0; row < (t.h+1)/2; row++ {
+		for x := 0; x < t.w; x++ {
+			cl := cell{top: pixel(x, row*2), bottom: pixel(x, row*2+1)}
+			i := x + row*t.w
+			if !t.redraw && t.cells[i] == cl {
+				continue
+			}
+			t.cells[i] = cl
+			if x != cursorX || row != cursorY {
+				fmt.Fprintf(&buf, "\x1b[%d;%dH", row+1, x+1)
+			}
+			if !colored || cl.top != fg {
+				fmt.Fprintf(&buf, "\x1b[38;2;%d;%d;%dm", cl.top.R, cl.top.G, cl.top.B)
+			}
+			if !colored || cl.bottom != back {
+				fmt.Fprintf(&buf, "\x1b[48;2;%d;%d;%dm", cl.bottom.R, cl.bottom.G, cl.bottom.B)
+			}
+			fg, back, colored = cl.top, cl.bottom, true
+			buf.WriteString(upperHalfBox)
+			cursorX, cursorY = x+1, row
+		}
+	}
+	t.redraw = false
+
+	if buf.Len() > 0 {
+		if _, err := t.out.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	c.SetDraw(false)
+	return nil
+}
+
+// HandleEvent processes the user's inputs, Ctrl+C and Escape quit
+// Keys are released keyHold after the terminal last sent them. When the terminal is resized, the display is drawn
+// again on a cleared screen, as terminals move or cut the text already written.
+func (t *Terminal) HandleEvent(c *emulator.Chip8) bool {
+	now := time.Now()
+	for {
+		select {
+		case <-t.resized:
+			t.clear = true
+			c.SetDraw(true)
+		case chunk, ok := <-t.input:
+			if !ok {
+				return true
+			}
+			var keys []string
+			keys, t.pending = splitKeys(t.pending + chunk)
+			t.pendingAt = now
+			for _, key := range keys {
+				if key == keyCtrlC || key == keyEscape {
+					return true
+				}
+				if len(key) == 1 {
+					key = strings.ToLower(key)
+				}
+				if t.hotkey(key) {
+					c.SetDraw(true)
+					continue
+				}
+				if value, ok := t.keymap[key]; ok {
+					c.SetKeyDown(value)
+					t.pressed[value] = now
+				}
+			}
+		default:
+			if t.pending != "" && now.Sub(t.pendingAt) >= escapeDelay {
+				// Nothing followed: a lone escape is the Escape key, the start of a sequence is dropped
+				if t.pending == keyEscape {
+					return true
+				}
+				t.pending = ""
+			}
+			for value, at := range t.pressed {
+				if now.Sub(at) >= keyHold {
+					c.SetKeyUp(value)
+					delete(t.pressed, value)
+				}
+			}
+			return false
+		}
+	}
+}
+
+// hotkey runs the action of a hotkey pressed, it tells if the key is a hotkey
+func (t *Terminal) hotkey(key string) bool {
+	switch key {
+	case terminalPaletteKey:
+		t.SetPalette(nextPalette(t.palette, 1))
+		t.osd.Message("Palette %s", t.palette.Name)
+	case terminalStatsKey:
+		t.osd.ToggleStats()
+	case terminalPauseKey:
+		t.controls.TogglePause()
+		if !t.controls.Paused {
+			t.osd.Message("Resumed")
+		}
+	case terminalSlowerKey:
+		t.controls.Slower()
+		t.osd.Message("Speed x%g", t.controls.Speed)
+	case terminalFasterKey, "=":
+		t.controls.Faster()
+		t.osd.Message("Speed x%g", t.controls.Speed)
+	default:
+		return false
+	}
+	return true
+}
+
+// arrows are the final bytes of the escape sequences of the arrows
+var arrows = map[byte]string{'A': keyUp, 'B': keyDown, 'C': keyRight, 'D': keyLeft}
+
+// splitKeys splits what the terminal sent into keys, and gets the escape sequence left incomplete at its end
+// CSI sequences (ESC [, parameters and a final byte) and SS3 sequences (ESC O and a byte) of the arrows are given as
+// keyUp, keyDown, keyRight and keyLeft, whatever their modifiers. The other sequences, such as function keys, and
+// the keys pressed with Alt are dropped. An escape followed by another one is the Escape key.
+func splitKeys(data string) ([]string, string) {
+	var keys []string
+	for len(data) > 0 {
+		if data[0] != '\x1b' {
+			keys = append(keys, data[:1])
+			data = data[1:]
+			continue
+		}
+		if len(data) == 1 {
+			return keys, data
+		}
+		switch data[1] {
+		case '\x1b':
+			keys = append(keys, keyEscape)
+			data = data[1:]
+		case '[':
+			// Parameters and intermediate bytes are from 0x20 to 0x3F, the final byte from 0x40 to 0x7E
+			n := 2
+			for n < len(data) && data[n] >= 0x20 && data[n] <= 0x3F {
+				n++
+			}
+			if n == len(data) {
+				return keys, data
+			}
+			if arrow, ok := arrows[data[n]]; ok {
+				keys = append(keys, arrow)
+			}
+			data = data[n+1:]
+		case 'O':
+			if len(data) == 2 {
+				return keys, data
+			}
+			if arrow, ok := arrows[data[2]]; ok {
+				keys = append(keys, arrow)
+			}
+			data = data[3:]
+		default:
+			data = data[2:]
+		}
+	}
+	return keys, ""
+}
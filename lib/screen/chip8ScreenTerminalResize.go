@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+package screen
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyResize sends to resized when the terminal is resized
+func notifyResize(resized chan os.Signal) {
+	signal.Notify(resized, syscall.SIGWINCH)
+}
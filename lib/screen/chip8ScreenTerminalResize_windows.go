@@ -0,0 +1,7 @@
+package screen
+
+import "os"
+
+// notifyResize does nothing, Windows has no signal telling that the terminal is resized
+func notifyResize(resized chan os.Signal) {
+}
@@ -0,0 +1,97 @@
+package screen
+
+import (
+	"bytes"
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitKeys(t *testing.T) {
+	for data, expected := range map[string][]string{
+		"az":               {"a", "z"},
+		"\x1b[A\x1bOB":     {keyUp, keyDown},
+		"\x1b[1;5C\x1bOD":  {keyRight, keyLeft},
+		"a\x1b[15~\x1bOPb": {"a", "b"},
+		"\x1bo\x1bp":       nil,
+		"\x1b\x1b[A":       {keyEscape, keyUp},
+	} {
+		keys, rest := splitKeys(data)
+		assert.Equal(t, expected, keys, "%q", data)
+		assert.Empty(t, rest, "%q", data)
+	}
+
+	// Sequences left incomplete wait for the next read
+	for data, expected := range map[string]string{
+		"\x1b":         "\x1b",
+		"x\x1b[":       "\x1b[",
+		"x\x1b[1;5":    "\x1b[1;5",
+		"\x1b[Ax\x1bO": "\x1bO",
+	} {
+		_, rest := splitKeys(data)
+		assert.Equal(t, expected, rest, "%q", data)
+	}
+}
+
+func TestTerminal_HandleEvent(t *testing.T) {
+	term := NewTerminal()
+	term.BindActions(map[string]int{"up": 0x5})
+	c := emulator.New()
+	c.Initialize(nil)
+
+	// An arrow split between reads is not taken for Escape, O and A
+	term.input <- "\x1b"
+	assert.False(t, term.HandleEvent(c))
+	term.input <- "OA"
+	assert.False(t, term.HandleEvent(c))
+	assert.Contains(t, term.pressed, 0x5)
+	assert.NotContains(t, term.pressed, 0x4)
+	assert.False(t, term.osd.showStats)
+
+	// An escape followed by nothing is the Escape key
+	term.input <- "\x1b"
+	assert.False(t, term.HandleEvent(c))
+	term.pendingAt = time.Now().Add(-escapeDelay)
+	assert.True(t, term.HandleEvent(c))
+}
+
+func TestTerminal_Draw(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal()
+	term.out = &out
+	c := emulator.New()
+	c.Initialize(nil)
+	assert.Nil(t, c.LoadBytes([]byte{
+		0xA2, 0x06, // 200: LD I, 0x206
+		0xD0, 0x01, // 202: DRW V0, V0, 1
+		0x12, 0x04, // 204: JP 0x204
+		0x80, // 206: the top left pixel
+	}))
+
+	// The first frame draws every cell, 2 rows of pixels per row of the terminal
+	assert.Nil(t, term.Draw(c))
+	assert.True(t, strings.HasPrefix(out.String(), clearScreen))
+	assert.Equal(t, 64*16, strings.Count(out.String(), upperHalfBox))
+
+	// Then only the cells that changed
+	out.Reset()
+	assert.Nil(t, c.EmulateCycle())
+	assert.Nil(t, c.EmulateCycle())
+	assert.Nil(t, term.Draw(c))
+	assert.True(t, strings.HasPrefix(out.String(), "\x1b[1;1H"))
+	assert.Equal(t, 1, strings.Count(out.String(), upperHalfBox))
+
+	out.Reset()
+	assert.Nil(t, term.Draw(c))
+	assert.Empty(t, out.String())
+
+	// A resized terminal is cleared and every cell drawn again
+	term.resized <- nil
+	assert.False(t, term.HandleEvent(c))
+	assert.True(t, c.NeedDraw())
+	assert.Nil(t, term.Draw(c))
+	assert.True(t, strings.HasPrefix(out.String(), clearScreen))
+	assert.Equal(t, 64*16, strings.Count(out.String(), upperHalfBox))
+}
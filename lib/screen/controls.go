@@ -0,0 +1,43 @@
+package screen
+
+// speeds are the speeds the emulation can run at, as multiples of its normal speed
+var speeds = []float64{0.25, 0.5, 1, 2, 4, 8}
+
+// Controls are the state of the emulation changed by the hotkeys and the menu of the screens, read by the main loop
+type Controls struct {
+	Paused bool
+	Speed  float64
+	// Reset asks to start the rom again, Load to run another rom, the main loop clears them once done
+	Reset bool
+	Load  string
+}
+
+// NewControls creates controls running the emulation at its normal speed
+func NewControls() *Controls {
+	return &Controls{Speed: 1}
+}
+
+// TogglePause pauses or resumes the emulation
+func (c *Controls) TogglePause() {
+	c.Paused = !c.Paused
+}
+
+// Faster runs the emulation at the next speed, up to the fastest
+func (c *Controls) Faster() {
+	for _, speed := range speeds {
+		if speed > c.Speed {
+			c.Speed = speed
+			return
+		}
+	}
+}
+
+// Slower runs the emulation at the previous speed, down to the slowest
+func (c *Controls) Slower() {
+	for i := len(speeds) - 1; i >= 0; i-- {
+		if speeds[i] < c.Speed {
+			c.Speed = speeds[i]
+			return
+		}
+	}
+}
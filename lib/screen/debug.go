@@ -0,0 +1,107 @@
+package screen
+
+import (
+	"fmt"
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"image"
+	"image/color"
+)
+
+// spriteColors are the colors of the outlines of the sprites of the debug overlay, given to the sprites in turn
+var spriteColors = []color.RGBA{
+	{0x00, 0xBF, 0xFF, 0xFF}, // sky blue
+	{0xFF, 0xA5, 0x00, 0xFF}, // orange
+	{0x7F, 0xFF, 0x00, 0xFF}, // chartreuse
+	{0xFF, 0x14, 0x93, 0xFF}, // pink
+	{0xFF, 0xFF, 0x00, 0xFF}, // yellow
+	{0x94, 0x00, 0xD3, 0xFF}, // violet
+	{0x00, 0xFF, 0xFF, 0xFF}, // cyan
+	{0xFF, 0x63, 0x47, 0xFF}, // tomato
+}
+
+// collisionColor marks the pixels a sprite turned off, setting VF
+var collisionColor = color.RGBA{0xFF, 0x00, 0x00, 0xC0}
+
+// spriteRects gets the rectangles a sprite covers on a display of w x h pixels
+// A sprite crossing the edges of the display is clipped, or cut in up to 4 rectangles when it wraps around.
+func spriteRects(s emulator.Sprite, w, h int) []image.Rectangle {
+	display := image.Rect(0, 0, w, h)
+	r := image.Rect(s.X, s.Y, s.X+s.Width, s.Y+s.Height)
+	rects := []image.Rectangle{r.Intersect(display)}
+	if s.Wrap {
+		for _, offset := range []image.Point{{-w, 0}, {0, -h}, {-w, -h}} {
+			if part := r.Add(offset).Intersect(display); !part.Empty() {
+				rects = append(rects, part)
+			}
+		}
+	}
+	return rects
+}
+
+// spriteAt gets the last sprite drawn over a pixel of a display of w x h pixels
+func spriteAt(sprites []emulator.Sprite, p image.Point, w, h int) (emulator.Sprite, bool) {
+	for i := len(sprites) - 1; i >= 0; i-- {
+		for _, r := range spriteRects(sprites[i], w, h) {
+			if p.In(r) {
+				return sprites[i], true
+			}
+		}
+	}
+	return emulator.Sprite{}, false
+}
+
+// describeSprite gets the lines describing a sprite when the mouse hovers it
+func describeSprite(s emulator.Sprite) []string {
+	lines := []string{
+		fmt.Sprintf("I=0x%03X %dx%d", s.Address, s.Width, s.Height),
+		fmt.Sprintf("X=%d Y=%d PC=0x%03X", s.X, s.Y, s.PC),
+	}
+	if len(s.Collisions) > 0 {
+		lines = append(lines, fmt.Sprintf("%d collisions", len(s.Collisions)))
+	}
+	return lines
+}
+
+// displayToWindow gets the area of the window covered by a rectangle of the display of w x h pixels, drawn in dst
+func displayToWindow(r, dst image.Rectangle, w, h int) image.Rectangle {
+	return image.Rect(
+		dst.Min.X+r.Min.X*dst.Dx()/w, dst.Min.Y+r.Min.Y*dst.Dy()/h,
+		dst.Min.X+r.Max.X*dst.Dx()/w, dst.Min.Y+r.Max.Y*dst.Dy()/h,
+	)
+}
+
+// windowToDisplay gets the pixel of the display of w x h pixels drawn in dst under a point of the window
+// It tells if the point is over the display.
+func windowToDisplay(p image.Point, dst image.Rectangle, w, h int) (image.Point, bool) {
+	if !p.In(dst) {
+		return image.Point{}, false
+	}
+	return image.Pt((p.X-dst.Min.X)*w/dst.Dx(), (p.Y-dst.Min.Y)*h/dst.Dy()), true
+}
+
+// drawTooltip draws lines of text below and right of a point, moved left or up to fit in the image
+func drawTooltip(img *image.RGBA, p image.Point, lines []string) {
+	lineH := glyphH + glyphSpace*2
+	w := 0
+	for _, line := range lines {
+		if lw := textWidth(line) + glyphSpace*2; lw > w {
+			w = lw
+		}
+	}
+	x, y := p.X+glyphW, p.Y+glyphH
+	if x+w > img.Rect.Max.X {
+		x = p.X - glyphW - w
+	}
+	if y+len(lines)*lineH > img.Rect.Max.Y {
+		y = img.Rect.Max.Y - len(lines)*lineH
+	}
+	if x < img.Rect.Min.X {
+		x = img.Rect.Min.X
+	}
+	if y < img.Rect.Min.Y {
+		y = img.Rect.Min.Y
+	}
+	for i, line := range lines {
+		drawText(img, x, y+i*lineH, line)
+	}
+}
@@ -0,0 +1,58 @@
+package screen
+
+import (
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"testing"
+)
+
+func TestSpriteRects(t *testing.T) {
+	s := emulator.Sprite{X: 60, Y: 30, Width: 8, Height: 5}
+	assert.Equal(t, []image.Rectangle{image.Rect(60, 30, 64, 32)}, spriteRects(s, 64, 32))
+
+	// A wrapping sprite is cut at the right and bottom edges, its parts wrap to the other side
+	s.Wrap = true
+	assert.Equal(t, []image.Rectangle{
+		image.Rect(60, 30, 64, 32),
+		image.Rect(0, 30, 4, 32),
+		image.Rect(60, 0, 64, 3),
+		image.Rect(0, 0, 4, 3),
+	}, spriteRects(s, 64, 32))
+}
+
+func TestSpriteAt(t *testing.T) {
+	sprites := []emulator.Sprite{
+		{X: 0, Y: 0, Width: 8, Height: 4, Address: 0x200},
+		{X: 4, Y: 2, Width: 8, Height: 4, Address: 0x300},
+	}
+	s, ok := spriteAt(sprites, image.Pt(1, 1), 64, 32)
+	assert.True(t, ok)
+	assert.Equal(t, uint16(0x200), s.Address)
+
+	// The last sprite drawn is on top
+	s, ok = spriteAt(sprites, image.Pt(5, 3), 64, 32)
+	assert.True(t, ok)
+	assert.Equal(t, uint16(0x300), s.Address)
+
+	_, ok = spriteAt(sprites, image.Pt(20, 20), 64, 32)
+	assert.False(t, ok)
+}
+
+func TestDescribeSprite(t *testing.T) {
+	s := emulator.Sprite{X: 12, Y: 8, Width: 8, Height: 5, Address: 0x2A0, PC: 0x230, Collisions: []int{1, 2}}
+	assert.Equal(t, []string{"I=0x2A0 8x5", "X=12 Y=8 PC=0x230", "2 collisions"}, describeSprite(s))
+}
+
+func TestDisplayToWindow(t *testing.T) {
+	dst := image.Rect(0, 180, 1280, 820)
+	assert.Equal(t, image.Rect(20, 200, 180, 300), displayToWindow(image.Rect(1, 1, 9, 6), dst, 64, 32))
+
+	p, ok := windowToDisplay(image.Pt(25, 215), dst, 64, 32)
+	assert.True(t, ok)
+	assert.Equal(t, image.Pt(1, 1), p)
+
+	// The black bars are outside of the display
+	_, ok = windowToDisplay(image.Pt(25, 100), dst, 64, 32)
+	assert.False(t, ok)
+}
@@ -0,0 +1,235 @@
+package screen
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Size of the cells the effects draw each pixel in
+const (
+	effectCell = 4
+	ledCell    = 8
+)
+
+// Strength of the effects, the part of its color a pixel keeps where it is darkened
+const (
+	scanlineBrightness = 0.4
+	gridBrightness     = 0.6
+	ledBoardBrightness = 0.3
+	vignetteBrightness = 0.5
+)
+
+// Filters are the filters that can be chained with ParseFilters, by ID
+var Filters = map[string]func() FilterInterface{
+	"blend":       func() FilterInterface { return NewBlend(2) },
+	"epx":         func() FilterInterface { return &EPX{} },
+	"grid":        func() FilterInterface { return &PixelGrid{} },
+	"led":         func() FilterInterface { return &LED{} },
+	"persistence": func() FilterInterface { return NewPersistence(0.6) },
+	"scale2x":     func() FilterInterface { return &Scale2x{} },
+	"scale3x":     func() FilterInterface { return &Scale3x{} },
+	"scanlines":   func() FilterInterface { return &Scanlines{} },
+	"vignette":    func() FilterInterface { return &Vignette{} },
+}
+
+// FilterIDs gets the IDs of the filters, sorted
+func FilterIDs() []string {
+	var ids []string
+	for id := range Filters {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ParseFilters creates the filters of a comma-separated list of IDs, in order
+func ParseFilters(list string) ([]FilterInterface, error) {
+	var filters []FilterInterface
+	for _, id := range strings.Split(list, ",") {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if id == "" {
+			continue
+		}
+		newFilter, ok := Filters[id]
+		if !ok {
+			return nil, fmt.Errorf("unknown filter %q, known filters are %s", id, strings.Join(FilterIDs(), ", "))
+		}
+		filters = append(filters, newFilter())
+	}
+	return filters, nil
+}
+
+// reuse gets img if it has the given size, or a new image
+func reuse(img *image.RGBA, w, h int) *image.RGBA {
+	if img == nil || img.Rect.Dx() != w || img.Rect.Dy() != h {
+		return image.NewRGBA(image.Rect(0, 0, w, h))
+	}
+	return img
+}
+
+// pixelAt gets the color of a pixel, the pixels outside of the image get the color of the nearest edge
+func pixelAt(img *image.RGBA, x, y int) color.RGBA {
+	b := img.Rect
+	if x < 0 {
+		x = 0
+	} else if x >= b.Dx() {
+		x = b.Dx() - 1
+	}
+	if y < 0 {
+		y = 0
+	} else if y >= b.Dy() {
+		y = b.Dy() - 1
+	}
+	return img.RGBAAt(b.Min.X+x, b.Min.Y+y)
+}
+
+// dim darkens a color, keeping the given part of it
+func dim(c color.RGBA, brightness float64) color.RGBA {
+	return color.RGBA{
+		R: uint8(float64(c.R)*brightness + 0.5),
+		G: uint8(float64(c.G)*brightness + 0.5),
+		B: uint8(float64(c.B)*brightness + 0.5),
+		A: c.A,
+	}
+}
+
+// mix gets the color at t between a and b
+func mix(a, b color.RGBA, t float64) color.RGBA {
+	return color.RGBA{
+		R: uint8(float64(a.R) + (float64(b.R)-float64(a.R))*t + 0.5),
+		G: uint8(float64(a.G) + (float64(b.G)-float64(a.G))*t + 0.5),
+		B: uint8(float64(a.B) + (float64(b.B)-float64(a.B))*t + 0.5),
+		A: uint8(float64(a.A) + (float64(b.A)-float64(a.A))*t + 0.5),
+	}
+}
+
+// cells draws each pixel of a frame in a cell of size x size pixels, cell gives the color of a pixel of the cell
+func cells(out *image.RGBA, f Frame, size int, cell func(c color.RGBA, x, y int) color.RGBA) *image.RGBA {
+	w, h := f.Image.Rect.Dx(), f.Image.Rect.Dy()
+	out = reuse(out, w*size, h*size)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := pixelAt(f.Image, x, y)
+			for cy := 0; cy < size; cy++ {
+				for cx := 0; cx < size; cx++ {
+					out.SetRGBA(x*size+cx, y*size+cy, cell(c, cx, cy))
+				}
+			}
+		}
+	}
+	return out
+}
+
+// Scanlines draws each pixel on 4 rows, the last one darkened as the gaps between the lines of a CRT
+type Scanlines struct {
+	out *image.RGBA
+}
+
+// Apply draws the frame with scanlines
+func (s *Scanlines) Apply(f Frame) Frame {
+	s.out = cells(s.out, f, effectCell, func(c color.RGBA, x, y int) color.RGBA {
+		if y == effectCell-1 {
+			return dim(c, scanlineBrightness)
+		}
+		return c
+	})
+	return Frame{Image: s.out, Background: f.Background}
+}
+
+// Animating is always false, scanlines only depend on the frame
+func (s *Scanlines) Animating() bool {
+	return false
+}
+
+// PixelGrid draws each pixel in a cell of 4x4 pixels, its last row and column darkened as the grid of an LCD
+type PixelGrid struct {
+	out *image.RGBA
+}
+
+// Apply draws the frame with a grid
+func (g *PixelGrid) Apply(f Frame) Frame {
+	g.out = cells(g.out, f, effectCell, func(c color.RGBA, x, y int) color.RGBA {
+		if x == effectCell-1 || y == effectCell-1 {
+			return dim(c, gridBrightness)
+		}
+		return c
+	})
+	return Frame{Image: g.out, Background: f.Background}
+}
+
+// Animating is always false, the grid only depends on the frame
+func (g *PixelGrid) Animating() bool {
+	return false
+}
+
+// LED draws each pixel as a round light on a dark board, the pixels that are off as unlit lights
+type LED struct {
+	out  *image.RGBA
+	mask []float64
+}
+
+// Apply draws the frame with round pixels
+func (l *LED) Apply(f Frame) Frame {
+	if l.mask == nil {
+		// How much of each pixel of a cell the light covers, smoothing its edge
+		radius := float64(ledCell)/2 - 0.5
+		l.mask = make([]float64, ledCell*ledCell)
+		for y := 0; y < ledCell; y++ {
+			for x := 0; x < ledCell; x++ {
+				d := math.Hypot(float64(x)+0.5-ledCell/2, float64(y)+0.5-ledCell/2)
+				l.mask[x+y*ledCell] = math.Max(0, math.Min(1, radius+0.5-d))
+			}
+		}
+	}
+	board := dim(f.Background, ledBoardBrightness)
+	l.out = cells(l.out, f, ledCell, func(c color.RGBA, x, y int) color.RGBA {
+		return mix(board, c, l.mask[x+y*ledCell])
+	})
+	return Frame{Image: l.out, Background: board}
+}
+
+// Animating is always false, the lights only depend on the frame
+func (l *LED) Animating() bool {
+	return false
+}
+
+// Vignette darkens the corners of the frame as the edges of a CRT, it keeps its size
+type Vignette struct {
+	out  *image.RGBA
+	mask []float64
+	w    int
+	h    int
+}
+
+// Apply darkens the frame towards its corners
+func (v *Vignette) Apply(f Frame) Frame {
+	w, h := f.Image.Rect.Dx(), f.Image.Rect.Dy()
+	if v.mask == nil || w != v.w || h != v.h {
+		v.w, v.h = w, h
+		v.mask = make([]float64, w*h)
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				dx := (float64(x)+0.5)/float64(w)*2 - 1
+				dy := (float64(y)+0.5)/float64(h)*2 - 1
+				v.mask[x+y*w] = 1 - (1-vignetteBrightness)*(dx*dx+dy*dy)/2
+			}
+		}
+	}
+
+	v.out = reuse(v.out, w, h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v.out.SetRGBA(x, y, dim(pixelAt(f.Image, x, y), v.mask[x+y*w]))
+		}
+	}
+	return Frame{Image: v.out, Background: f.Background}
+}
+
+// Animating is always false, the vignette only depends on the frame
+func (v *Vignette) Animating() bool {
+	return false
+}
@@ -0,0 +1,58 @@
+package screen
+
+import (
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// diagonal is a frame of 2x2 pixels, lit from the top left to the bottom right
+func diagonal() Frame {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	black, white := color.RGBA{0, 0, 0, 0xFF}, color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	img.SetRGBA(0, 0, white)
+	img.SetRGBA(1, 0, black)
+	img.SetRGBA(0, 1, black)
+	img.SetRGBA(1, 1, white)
+	return Frame{Image: img, Background: black}
+}
+
+func TestParseFilters(t *testing.T) {
+	filters, err := ParseFilters("scale2x, Scanlines,")
+	assert.Nil(t, err)
+	assert.Len(t, filters, 2)
+	assert.IsType(t, &Scale2x{}, filters[0])
+	assert.IsType(t, &Scanlines{}, filters[1])
+
+	filters, err = ParseFilters("")
+	assert.Nil(t, err)
+	assert.Empty(t, filters)
+
+	_, err = ParseFilters("scale2x,hq4x")
+	assert.NotNil(t, err)
+}
+
+func TestScalers(t *testing.T) {
+	for _, filter := range []FilterInterface{&Scale2x{}, &EPX{}} {
+		img := filter.Apply(diagonal()).Image
+		assert.Equal(t, image.Rect(0, 0, 4, 4), img.Rect)
+		// The diagonal is filled in between the lit pixels
+		assert.Equal(t, uint8(0xFF), img.RGBAAt(2, 1).R)
+		assert.Equal(t, uint8(0xFF), img.RGBAAt(1, 2).R)
+		assert.Equal(t, uint8(0), img.RGBAAt(3, 0).R)
+		assert.Equal(t, uint8(0), img.RGBAAt(0, 3).R)
+	}
+
+	img := (&Scale3x{}).Apply(diagonal()).Image
+	assert.Equal(t, image.Rect(0, 0, 6, 6), img.Rect)
+	assert.Equal(t, uint8(0xFF), img.RGBAAt(3, 2).R)
+	assert.Equal(t, uint8(0), img.RGBAAt(5, 0).R)
+}
+
+func TestScanlines(t *testing.T) {
+	img := (&Scanlines{}).Apply(diagonal()).Image
+	assert.Equal(t, image.Rect(0, 0, 8, 8), img.Rect)
+	assert.Equal(t, uint8(0xFF), img.RGBAAt(0, 2).R)
+	assert.Equal(t, uint8(0x66), img.RGBAAt(0, 3).R)
+}
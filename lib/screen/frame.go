@@ -0,0 +1,69 @@
+package screen
+
+import (
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"image"
+	"image/color"
+)
+
+// Frame is an image of the display, on its way from the emulator to the window
+type Frame struct {
+	// Image holds the colors of the pixels
+	Image *image.RGBA
+	// Background is the color of the pixels that are off
+	Background color.RGBA
+}
+
+// FilterInterface post-processes the frames of the display, it never changes the emulator
+type FilterInterface interface {
+	// Apply filters a frame, the filtered frame may have another size
+	Apply(f Frame) Frame
+	// Animating tells if the filter would change the frame even if the emulator drew nothing
+	Animating() bool
+}
+
+// renderFrame renders the gfx of the Chip8 with a palette, in img if it has the resolution of the Chip8
+func renderFrame(c *emulator.Chip8, palette Palette, img *image.RGBA) Frame {
+	w, h := c.GetResolution()
+	if img == nil || img.Rect.Dx() != w || img.Rect.Dy() != h {
+		img = image.NewRGBA(image.Rect(0, 0, w, h))
+	}
+
+	// CHIP-8X's color board replaces the colors, the foreground by zones of 8x1 pixels
+	background, zones, hasColors := c.GetColorLayer()
+	bg := palette.color(0)
+	if hasColors {
+		bg = chip8XBackgrounds[background]
+	}
+	for i, value := range c.GetGFX() {
+		rgba := bg
+		switch {
+		case value == 0:
+		case hasColors:
+			x, y := i%w, i/w
+			rgba = chip8XForegrounds[zones[x/8+y*w/8]]
+		default:
+			rgba = palette.color(value)
+		}
+		img.Pix[i*4], img.Pix[i*4+1], img.Pix[i*4+2], img.Pix[i*4+3] = rgba.R, rgba.G, rgba.B, rgba.A
+	}
+	return Frame{Image: img, Background: bg}
+}
+
+// applyFilters applies filters to a frame, in order
+func applyFilters(filters []FilterInterface, f Frame) Frame {
+	for _, filter := range filters {
+		f = filter.Apply(f)
+	}
+	return f
+}
+
+// animating tells if one of the filters is animating
+func animating(filters []FilterInterface) bool {
+	for _, filter := range filters {
+		if filter.Animating() {
+			return true
+		}
+	}
+	return false
+}
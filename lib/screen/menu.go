@@ -0,0 +1,224 @@
+package screen
+
+import (
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"image"
+	"image/color"
+	"image/draw"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// menuVisibleItems is how many items of a page are shown at once, the others scroll into view
+const menuVisibleItems = 12
+
+// osdHighlight is the color of the line of the item selected in the menu
+var osdHighlight = color.RGBA{0x40, 0x40, 0x40, 0xFF}
+
+// menuKey is a key of the menu, the screens map their keys to them
+type menuKey int
+
+// Keys of the menu
+const (
+	menuUp menuKey = iota
+	menuDown
+	menuLeft
+	menuRight
+	menuEnter
+	menuBack
+)
+
+// QuirksProfile is a set of quirks the menu can switch to
+type QuirksProfile struct {
+	Name   string
+	Quirks emulator.Quirks
+}
+
+// MenuOptions are what the menu offers besides the settings of the screen
+type MenuOptions struct {
+	// ROMDir is the directory the rom browser opens in
+	ROMDir string
+	// Describe gets lines describing a rom file, such as its title and platform in the rom database
+	Describe func(path string) []string
+	// Profiles are the quirks profiles the menu switches between
+	Profiles []QuirksProfile
+}
+
+// menuItem is a line of a page of the menu
+// Items with a value are changed with left and right, items with activate are run with Enter.
+type menuItem struct {
+	label    string
+	value    func() string
+	change   func(delta int)
+	activate func()
+	// path is the file or directory of the items of the rom browser
+	path string
+}
+
+// menuPage is a page of the menu, a list of items with one selected
+type menuPage struct {
+	title    string
+	items    []menuItem
+	selected int
+	// describe gets lines describing an item, shown under the page
+	describe func(item menuItem) []string
+	// info caches the lines describing the selected item
+	info     []string
+	infoItem int
+}
+
+// Menu is the menu opened over the game, a stack of pages browsed with the keyboard
+type Menu struct {
+	pages []*menuPage
+}
+
+// isOpen tells if the menu is shown
+func (m *Menu) isOpen() bool {
+	return len(m.pages) > 0
+}
+
+// push opens a page over the current one
+func (m *Menu) push(p *menuPage) {
+	p.infoItem = -1
+	m.pages = append(m.pages, p)
+}
+
+// close closes all the pages of the menu
+func (m *Menu) close() {
+	m.pages = nil
+}
+
+// key moves in the current page, or runs its selected item
+func (m *Menu) key(k menuKey) {
+	p := m.pages[len(m.pages)-1]
+	if len(p.items) == 0 && k != menuBack {
+		return
+	}
+	switch k {
+	case menuUp:
+		p.selected = (p.selected + len(p.items) - 1) % len(p.items)
+	case menuDown:
+		p.selected = (p.selected + 1) % len(p.items)
+	case menuLeft, menuRight:
+		if item := p.items[p.selected]; item.change != nil {
+			delta := 1
+			if k == menuLeft {
+				delta = -1
+			}
+			item.change(delta)
+		}
+	case menuEnter:
+		if item := p.items[p.selected]; item.activate != nil {
+			item.activate()
+		} else if item.change != nil {
+			item.change(1)
+		}
+	case menuBack:
+		m.pages = m.pages[:len(m.pages)-1]
+	}
+}
+
+// lines gets the lines of the current page: its title, the visible items and what describes the selected one
+// selected is the index of the selected item's line.
+func (m *Menu) lines() (lines []string, selected int) {
+	p := m.pages[len(m.pages)-1]
+	lines = append(lines, p.title, "")
+
+	first := 0
+	if len(p.items) > menuVisibleItems {
+		first = p.selected - menuVisibleItems/2
+		if first < 0 {
+			first = 0
+		} else if first > len(p.items)-menuVisibleItems {
+			first = len(p.items) - menuVisibleItems
+		}
+	}
+	for i := first; i < len(p.items) && i < first+menuVisibleItems; i++ {
+		item := p.items[i]
+		line := item.label
+		if item.value != nil {
+			line += ": < " + item.value() + " >"
+		}
+		if i == p.selected {
+			selected = len(lines)
+		}
+		lines = append(lines, line)
+	}
+	if len(p.items) == 0 {
+		lines = append(lines, "(empty)")
+	}
+
+	if p.describe != nil && len(p.items) > 0 {
+		if p.infoItem != p.selected {
+			p.info, p.infoItem = p.describe(p.items[p.selected]), p.selected
+		}
+		if len(p.info) > 0 {
+			lines = append(lines, "")
+			lines = append(lines, p.info...)
+		}
+	}
+	return lines, selected
+}
+
+// draw draws the current page in the middle of an image, with a pixel of the font per pixel of the image
+func (m *Menu) draw(img *image.RGBA) {
+	lines, selected := m.lines()
+	lineH := glyphH + glyphSpace*2
+	w := 0
+	for _, line := range lines {
+		if lw := textWidth(line); lw > w {
+			w = lw
+		}
+	}
+	// Lines too wide for the image are cut, the first line can be read in any case
+	if max := img.Rect.Dx() - glyphSpace*4; w > max {
+		w = max
+	}
+	box := image.Rect(0, 0, w+glyphSpace*4, len(lines)*lineH+glyphSpace*2)
+	box = box.Add(img.Rect.Min).Add(image.Pt((img.Rect.Dx()-box.Dx())/2, (img.Rect.Dy()-box.Dy())/2))
+	draw.Draw(img, box, image.NewUniform(osdBox), image.Point{}, draw.Over)
+
+	inner := box.Inset(glyphSpace * 2)
+	for i, line := range lines {
+		y := box.Min.Y + glyphSpace + i*lineH
+		if i == selected {
+			draw.Draw(img, image.Rect(box.Min.X, y, box.Max.X, y+lineH), image.NewUniform(osdHighlight), image.Point{}, draw.Src)
+		}
+		drawGlyphs(img.SubImage(inner).(*image.RGBA), inner.Min.X, y+glyphSpace, line)
+	}
+}
+
+// browserPage lists the directories and files of a directory, choosing a file runs it with load
+// Hidden files are left out, directories come first.
+func browserPage(dir string, describe func(path string) []string, load func(path string), open func(dir string)) *menuPage {
+	p := &menuPage{title: "Roms in " + dir}
+	if parent := filepath.Dir(dir); parent != dir {
+		p.items = append(p.items, menuItem{label: "../", path: parent, activate: func() { open(parent) }})
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		p.describe = func(menuItem) []string { return []string{err.Error()} }
+		return p
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].IsDir() && !entries[j].IsDir() })
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			p.items = append(p.items, menuItem{label: entry.Name() + "/", path: path, activate: func() { open(path) }})
+		} else {
+			p.items = append(p.items, menuItem{label: entry.Name(), path: path, activate: func() { load(path) }})
+		}
+	}
+	p.describe = func(item menuItem) []string {
+		if describe == nil || strings.HasSuffix(item.label, "/") {
+			return nil
+		}
+		return describe(item.path)
+	}
+	return p
+}
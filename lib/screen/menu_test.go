@@ -0,0 +1,44 @@
+package screen
+
+import (
+	"github.com/stretchr/testify/assert"
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBrowserPage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "roms")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "pong.ch8"), []byte{0x12, 0x00}, 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, ".hidden"), nil, 0644))
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "games"), 0755))
+
+	var loaded, opened string
+	describe := func(path string) []string { return []string{"Described " + filepath.Base(path)} }
+	p := browserPage(dir, describe, func(path string) { loaded = path }, func(dir string) { opened = dir })
+
+	var m Menu
+	m.push(p)
+	lines, selected := m.lines()
+	assert.Equal(t, []string{"Roms in " + dir, "", "../", "games/", "pong.ch8"}, lines)
+	assert.Equal(t, 2, selected)
+
+	m.key(menuDown)
+	m.key(menuEnter)
+	assert.Equal(t, filepath.Join(dir, "games"), opened)
+
+	m.key(menuDown)
+	lines, selected = m.lines()
+	assert.Equal(t, 4, selected)
+	assert.Equal(t, "Described pong.ch8", lines[len(lines)-1])
+	m.key(menuEnter)
+	assert.Equal(t, filepath.Join(dir, "pong.ch8"), loaded)
+
+	m.draw(image.NewRGBA(image.Rect(0, 0, 160, 90)))
+	m.key(menuBack)
+	assert.False(t, m.isOpen())
+}
@@ -0,0 +1,196 @@
+package screen
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Size of the glyphs of the OSD's font, and of the space around them
+const (
+	glyphW     = 3
+	glyphH     = 5
+	glyphSpace = 1
+)
+
+// OSD settings
+const (
+	// osdMessageDuration is how long a message stays on screen
+	osdMessageDuration = 2 * time.Second
+	// osdMaxMessages is how many messages are shown at once, the oldest go first
+	osdMaxMessages = 3
+	// osdRows is how many lines of text fit in the height of the window, which sets the size of the text
+	osdRows = 24
+)
+
+// Colors of the OSD
+var (
+	osdText = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	osdBox  = color.RGBA{0x00, 0x00, 0x00, 0xA0}
+)
+
+// osdFont is a font of 3x5 pixels for the OSD, each row is 3 bits from left to right
+// Lower case letters are drawn upper case, and missing characters as a question mark.
+var osdFont = map[rune][glyphH]uint8{
+	'0': {0b111, 0b101, 0b101, 0b101, 0b111}, '1': {0b010, 0b110, 0b010, 0b010, 0b111},
+	'2': {0b111, 0b001, 0b111, 0b100, 0b111}, '3': {0b111, 0b001, 0b111, 0b001, 0b111},
+	'4': {0b101, 0b101, 0b111, 0b001, 0b001}, '5': {0b111, 0b100, 0b111, 0b001, 0b111},
+	'6': {0b111, 0b100, 0b111, 0b101, 0b111}, '7': {0b111, 0b001, 0b001, 0b010, 0b010},
+	'8': {0b111, 0b101, 0b111, 0b101, 0b111}, '9': {0b111, 0b101, 0b111, 0b001, 0b111},
+	'A': {0b010, 0b101, 0b111, 0b101, 0b101}, 'B': {0b110, 0b101, 0b110, 0b101, 0b110},
+	'C': {0b011, 0b100, 0b100, 0b100, 0b011}, 'D': {0b110, 0b101, 0b101, 0b101, 0b110},
+	'E': {0b111, 0b100, 0b110, 0b100, 0b111}, 'F': {0b111, 0b100, 0b110, 0b100, 0b100},
+	'G': {0b011, 0b100, 0b101, 0b101, 0b011}, 'H': {0b101, 0b101, 0b111, 0b101, 0b101},
+	'I': {0b111, 0b010, 0b010, 0b010, 0b111}, 'J': {0b001, 0b001, 0b001, 0b101, 0b010},
+	'K': {0b101, 0b101, 0b110, 0b101, 0b101}, 'L': {0b100, 0b100, 0b100, 0b100, 0b111},
+	'M': {0b101, 0b111, 0b111, 0b101, 0b101}, 'N': {0b110, 0b101, 0b101, 0b101, 0b101},
+	'O': {0b010, 0b101, 0b101, 0b101, 0b010}, 'P': {0b110, 0b101, 0b110, 0b100, 0b100},
+	'Q': {0b010, 0b101, 0b101, 0b110, 0b011}, 'R': {0b110, 0b101, 0b110, 0b101, 0b101},
+	'S': {0b011, 0b100, 0b010, 0b001, 0b110}, 'T': {0b111, 0b010, 0b010, 0b010, 0b010},
+	'U': {0b101, 0b101, 0b101, 0b101, 0b111}, 'V': {0b101, 0b101, 0b101, 0b101, 0b010},
+	'W': {0b101, 0b101, 0b111, 0b111, 0b101}, 'X': {0b101, 0b101, 0b010, 0b101, 0b101},
+	'Y': {0b101, 0b101, 0b010, 0b010, 0b010}, 'Z': {0b111, 0b001, 0b010, 0b100, 0b111},
+	' ': {0b000, 0b000, 0b000, 0b000, 0b000}, '.': {0b000, 0b000, 0b000, 0b000, 0b010},
+	',': {0b000, 0b000, 0b000, 0b010, 0b100}, ':': {0b000, 0b010, 0b000, 0b010, 0b000},
+	'-': {0b000, 0b000, 0b111, 0b000, 0b000}, '+': {0b000, 0b010, 0b111, 0b010, 0b000},
+	'=': {0b000, 0b111, 0b000, 0b111, 0b000}, '/': {0b001, 0b001, 0b010, 0b100, 0b100},
+	'%': {0b101, 0b001, 0b010, 0b100, 0b101}, '!': {0b010, 0b010, 0b010, 0b000, 0b010},
+	'?': {0b111, 0b001, 0b010, 0b000, 0b010}, '(': {0b001, 0b010, 0b010, 0b010, 0b001},
+	')': {0b100, 0b010, 0b010, 0b010, 0b100}, '[': {0b011, 0b010, 0b010, 0b010, 0b011},
+	']': {0b110, 0b010, 0b010, 0b010, 0b110}, '<': {0b001, 0b010, 0b100, 0b010, 0b001},
+	'>': {0b100, 0b010, 0b001, 0b010, 0b100}, '_': {0b000, 0b000, 0b000, 0b000, 0b111},
+	'#': {0b101, 0b111, 0b101, 0b111, 0b101}, '*': {0b000, 0b101, 0b010, 0b101, 0b000},
+	'\'': {0b010, 0b010, 0b000, 0b000, 0b000}, '"': {0b101, 0b101, 0b000, 0b000, 0b000},
+}
+
+// Stats are the performance of the emulation shown by the OSD
+type Stats struct {
+	// FPS is the number of frames emulated per second
+	FPS float64
+	// IPS is the number of instructions emulated per second
+	IPS float64
+}
+
+// osdMessage is a message of the OSD, shown until a given time
+type osdMessage struct {
+	text  string
+	until time.Time
+}
+
+// OSD is the on-screen display drawn over the game: the stats, the pause and the speed at the top left,
+// and the last messages at the bottom left
+type OSD struct {
+	showStats bool
+	stats     Stats
+	messages  []osdMessage
+}
+
+// NewOSD creates an OSD showing no stats
+func NewOSD() *OSD {
+	return &OSD{}
+}
+
+// ToggleStats shows or hides the stats
+func (o *OSD) ToggleStats() {
+	o.showStats = !o.showStats
+}
+
+// SetStats sets the stats shown
+func (o *OSD) SetStats(s Stats) {
+	o.stats = s
+}
+
+// Message shows a message for a few seconds
+func (o *OSD) Message(format string, args ...interface{}) {
+	o.messages = append(o.messages, osdMessage{text: fmt.Sprintf(format, args...), until: time.Now().Add(osdMessageDuration)})
+	if len(o.messages) > osdMaxMessages {
+		o.messages = o.messages[len(o.messages)-osdMaxMessages:]
+	}
+}
+
+// lines gets the lines at the top and at the bottom of the OSD, dropping the messages that expired
+func (o *OSD) lines(controls *Controls, now time.Time) (top, bottom []string) {
+	if o.showStats {
+		top = append(top, fmt.Sprintf("FPS %.0f IPS %s", o.stats.FPS, formatCount(o.stats.IPS)))
+	}
+	if controls.Paused {
+		top = append(top, "PAUSED")
+	}
+	if controls.Speed != 1 {
+		top = append(top, "SPEED X"+strconv.FormatFloat(controls.Speed, 'g', -1, 64))
+	}
+
+	var kept []osdMessage
+	for _, m := range o.messages {
+		if now.Before(m.until) {
+			kept = append(kept, m)
+			bottom = append(bottom, m.text)
+		}
+	}
+	o.messages = kept
+	return top, bottom
+}
+
+// visible tells if the OSD has something to show
+func (o *OSD) visible(controls *Controls) bool {
+	return o.showStats || controls.Paused || controls.Speed != 1 || len(o.messages) > 0
+}
+
+// draw draws the OSD over an image, with a pixel of the font per pixel of the image
+func (o *OSD) draw(img *image.RGBA, controls *Controls) {
+	top, bottom := o.lines(controls, time.Now())
+	lineH := glyphH + glyphSpace*2
+	for i, line := range top {
+		drawText(img, img.Rect.Min.X, img.Rect.Min.Y+i*lineH, line)
+	}
+	for i, line := range bottom {
+		drawText(img, img.Rect.Min.X, img.Rect.Max.Y-(len(bottom)-i)*lineH, line)
+	}
+}
+
+// drawText draws a line of text on a translucent box, whose top left corner is at x, y
+func drawText(img *image.RGBA, x, y int, text string) {
+	box := image.Rect(x, y, x+textWidth(text)+glyphSpace*2, y+glyphH+glyphSpace*2)
+	draw.Draw(img, box, image.NewUniform(osdBox), image.Point{}, draw.Over)
+	drawGlyphs(img, x+glyphSpace, y+glyphSpace, text)
+}
+
+// textWidth gets the width of a line of text in pixels
+func textWidth(text string) int {
+	return len([]rune(text))*(glyphW+glyphSpace) - glyphSpace
+}
+
+// drawGlyphs draws a line of text, whose top left corner is at x, y
+func drawGlyphs(img *image.RGBA, x, y int, text string) {
+	for _, r := range strings.ToUpper(text) {
+		glyph, ok := osdFont[r]
+		if !ok {
+			glyph = osdFont['?']
+		}
+		for row, bits := range glyph {
+			for col := 0; col < glyphW; col++ {
+				if bits&(1<<(glyphW-1-col)) != 0 {
+					if p := image.Pt(x+col, y+row); p.In(img.Rect) {
+						img.SetRGBA(p.X, p.Y, osdText)
+					}
+				}
+			}
+		}
+		x += glyphW + glyphSpace
+	}
+}
+
+// formatCount formats a large number with a K or M suffix
+func formatCount(n float64) string {
+	switch {
+	case n >= 1e6:
+		return fmt.Sprintf("%.1fM", n/1e6)
+	case n >= 1e3:
+		return fmt.Sprintf("%.0fK", n/1e3)
+	}
+	return fmt.Sprintf("%.0f", n)
+}
@@ -0,0 +1,42 @@
+package screen
+
+import (
+	"github.com/stretchr/testify/assert"
+	"image"
+	"testing"
+)
+
+func TestOSD(t *testing.T) {
+	controls := NewControls()
+	osd := NewOSD()
+	assert.False(t, osd.visible(controls))
+
+	osd.Message("Saved")
+	controls.Faster()
+	top, bottom := osd.lines(controls, osd.messages[0].until.Add(-1))
+	assert.Equal(t, []string{"SPEED X2"}, top)
+	assert.Equal(t, []string{"Saved"}, bottom)
+
+	// Messages go once their time is up
+	_, bottom = osd.lines(controls, osd.messages[0].until)
+	assert.Empty(t, bottom)
+
+	img := image.NewRGBA(image.Rect(0, 0, 64, 32))
+	osd.draw(img, controls)
+	// The top left corner of the S is lit, above it is the box
+	assert.Equal(t, osdText, img.RGBAAt(2, 1))
+	assert.Equal(t, osdBox, img.RGBAAt(1, 0))
+	assert.Equal(t, uint8(0), img.RGBAAt(63, 0).A)
+}
+
+func TestControls(t *testing.T) {
+	controls := NewControls()
+	controls.Slower()
+	controls.Slower()
+	controls.Slower()
+	assert.Equal(t, 0.25, controls.Speed)
+	for i := 0; i < 10; i++ {
+		controls.Faster()
+	}
+	assert.Equal(t, 8.0, controls.Speed)
+}
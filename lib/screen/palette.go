@@ -0,0 +1,90 @@
+package screen
+
+import (
+	"image/color"
+	"sort"
+)
+
+// Palette is the colors of the display, indexed by the value of a pixel: the background first, then the pixels
+// that are on. Palettes of 4 or 16 colors give a color to each combination of the planes of multi-plane modes.
+type Palette struct {
+	ID     string
+	Name   string
+	Colors []color.RGBA
+}
+
+// Built-in palettes
+var (
+	// PaletteClassic is white on black, the default
+	PaletteClassic = Palette{ID: "classic", Name: "Classic", Colors: []color.RGBA{
+		{0x00, 0x00, 0x00, 0xFF}, {0xFF, 0xFF, 0xFF, 0xFF},
+	}}
+	// PaletteAmber is an amber monochrome monitor
+	PaletteAmber = Palette{ID: "amber", Name: "Amber", Colors: []color.RGBA{
+		{0x1A, 0x0F, 0x00, 0xFF}, {0xFF, 0xB0, 0x00, 0xFF},
+	}}
+	// PaletteGreen is a green phosphor monochrome monitor
+	PaletteGreen = Palette{ID: "green", Name: "Green phosphor", Colors: []color.RGBA{
+		{0x0A, 0x1A, 0x0A, 0xFF}, {0x33, 0xFF, 0x33, 0xFF},
+	}}
+	// PaletteLCD is the greenish LCD of handheld consoles, with 4 shades
+	PaletteLCD = Palette{ID: "lcd", Name: "LCD", Colors: []color.RGBA{
+		{0x9B, 0xBC, 0x0F, 0xFF}, {0x0F, 0x38, 0x0F, 0xFF}, {0x8B, 0xAC, 0x0F, 0xFF}, {0x30, 0x62, 0x30, 0xFF},
+	}}
+	// PaletteOcto is Octo's default colors: background, fill, fill 2 and blend
+	PaletteOcto = Palette{ID: "octo", Name: "Octo", Colors: []color.RGBA{
+		{0x99, 0x66, 0x00, 0xFF}, {0xFF, 0xCC, 0x00, 0xFF}, {0xFF, 0x66, 0x00, 0xFF}, {0x66, 0x22, 0x00, 0xFF},
+	}}
+)
+
+// Palettes are the built-in palettes, by ID
+var Palettes = map[string]Palette{
+	PaletteClassic.ID: PaletteClassic,
+	PaletteAmber.ID:   PaletteAmber,
+	PaletteGreen.ID:   PaletteGreen,
+	PaletteLCD.ID:     PaletteLCD,
+	PaletteOcto.ID:    PaletteOcto,
+}
+
+// PaletteIDs gets the IDs of the built-in palettes, sorted
+func PaletteIDs() []string {
+	var ids []string
+	for id := range Palettes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// nextPalette gets the built-in palette delta palettes after the given one, in the order of their IDs
+// Palettes that are not built-in, such as a rom's colors, are followed by the first built-in palette.
+func nextPalette(p Palette, delta int) Palette {
+	ids := PaletteIDs()
+	for i, id := range ids {
+		if id == p.ID {
+			return Palettes[ids[((i+delta)%len(ids)+len(ids))%len(ids)]]
+		}
+	}
+	return Palettes[ids[0]]
+}
+
+// withColors gets the palette with its first colors replaced by the given ones
+func (p Palette) withColors(colors []color.RGBA) Palette {
+	custom := Palette{ID: "custom", Name: "Custom", Colors: append([]color.RGBA{}, p.Colors...)}
+	for i, c := range colors {
+		if i < len(custom.Colors) {
+			custom.Colors[i] = c
+		} else {
+			custom.Colors = append(custom.Colors, c)
+		}
+	}
+	return custom
+}
+
+// color gets the color of a pixel's value, values past the end of the palette get its last color
+func (p Palette) color(value uint8) color.RGBA {
+	if int(value) >= len(p.Colors) {
+		return p.Colors[len(p.Colors)-1]
+	}
+	return p.Colors[value]
+}
@@ -0,0 +1,109 @@
+package screen
+
+import (
+	"bytes"
+	"image"
+)
+
+// Persistence emulates the phosphor of CRT monitors: pixels light up at once and fade out over a few frames,
+// which hides the flicker of sprites erased and drawn again by XOR
+type Persistence struct {
+	strength float64
+	history  []float64
+	settled  bool
+}
+
+// NewPersistence creates a phosphor persistence filter, strength is the part of its color a pixel that turns off
+// keeps at each frame, from 0 for none to 1 for forever
+func NewPersistence(strength float64) *Persistence {
+	return &Persistence{strength: strength, settled: true}
+}
+
+// Apply fades the pixels that turned off towards the background
+func (p *Persistence) Apply(f Frame) Frame {
+	pix := f.Image.Pix
+	if len(p.history) != len(pix) {
+		p.history = make([]float64, len(pix))
+		for i, v := range pix {
+			p.history[i] = float64(v)
+		}
+		return f
+	}
+
+	p.settled = true
+	bg := [4]uint8{f.Background.R, f.Background.G, f.Background.B, f.Background.A}
+	for i := 0; i < len(pix); i += 4 {
+		if pix[i] != bg[0] || pix[i+1] != bg[1] || pix[i+2] != bg[2] {
+			// Lit pixels are shown as they are
+			for c := 0; c < 4; c++ {
+				p.history[i+c] = float64(pix[i+c])
+			}
+			continue
+		}
+		for c := 0; c < 4; c++ {
+			target := float64(bg[c])
+			faded := target + (p.history[i+c]-target)*p.strength
+			if faded-target < 0.5 && target-faded < 0.5 {
+				faded = target
+			} else {
+				p.settled = false
+			}
+			p.history[i+c] = faded
+			pix[i+c] = uint8(faded + 0.5)
+		}
+	}
+	return f
+}
+
+// Animating tells if pixels are still fading out
+func (p *Persistence) Animating() bool {
+	return !p.settled
+}
+
+// Blend averages the last frames, so a sprite drawn every other frame is shown at half its brightness
+type Blend struct {
+	frames [][]byte
+	next   int
+	stable int
+}
+
+// NewBlend creates a filter blending the last n frames
+func NewBlend(n int) *Blend {
+	return &Blend{frames: make([][]byte, n)}
+}
+
+// Apply replaces the frame by the average of the last frames
+func (b *Blend) Apply(f Frame) Frame {
+	pix := f.Image.Pix
+	last := b.frames[(b.next+len(b.frames)-1)%len(b.frames)]
+	if len(last) != len(pix) {
+		// The first frame, or a frame of another size, fills the history
+		for i := range b.frames {
+			b.frames[i] = append([]byte{}, pix...)
+		}
+		b.stable = len(b.frames)
+		return f
+	}
+	if bytes.Equal(last, pix) {
+		b.stable++
+	} else {
+		b.stable = 1
+	}
+	b.frames[b.next] = append(b.frames[b.next][:0], pix...)
+	b.next = (b.next + 1) % len(b.frames)
+
+	out := image.NewRGBA(f.Image.Rect)
+	for i := range out.Pix {
+		sum := 0
+		for _, frame := range b.frames {
+			sum += int(frame[i])
+		}
+		out.Pix[i] = uint8((sum + len(b.frames)/2) / len(b.frames))
+	}
+	return Frame{Image: out, Background: f.Background}
+}
+
+// Animating tells if the last frames still differ
+func (b *Blend) Animating() bool {
+	return b.stable < len(b.frames)
+}
@@ -0,0 +1,41 @@
+package screen
+
+import (
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func testFrame(v uint8) Frame {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	img.Pix[0], img.Pix[1], img.Pix[2], img.Pix[3] = v, v, v, 0xFF
+	return Frame{Image: img, Background: color.RGBA{0, 0, 0, 0xFF}}
+}
+
+func TestPersistence(t *testing.T) {
+	p := NewPersistence(0.5)
+	assert.Equal(t, uint8(200), p.Apply(testFrame(200)).Image.Pix[0])
+	assert.False(t, p.Animating())
+
+	assert.Equal(t, uint8(100), p.Apply(testFrame(0)).Image.Pix[0])
+	assert.True(t, p.Animating())
+	assert.Equal(t, uint8(50), p.Apply(testFrame(0)).Image.Pix[0])
+
+	for i := 0; i < 10; i++ {
+		p.Apply(testFrame(0))
+	}
+	assert.False(t, p.Animating())
+	assert.Equal(t, uint8(0), p.Apply(testFrame(0)).Image.Pix[0])
+}
+
+func TestBlend(t *testing.T) {
+	b := NewBlend(2)
+	assert.Equal(t, uint8(200), b.Apply(testFrame(200)).Image.Pix[0])
+	assert.False(t, b.Animating())
+
+	assert.Equal(t, uint8(100), b.Apply(testFrame(0)).Image.Pix[0])
+	assert.True(t, b.Animating())
+	assert.Equal(t, uint8(0), b.Apply(testFrame(0)).Image.Pix[0])
+	assert.False(t, b.Animating())
+}
@@ -0,0 +1,163 @@
+package screen
+
+import (
+	"image"
+)
+
+// neighbours are the colors around a pixel E of a frame, A B C above it, D and F beside it, G H I below it
+type neighbours struct {
+	a, b, c, d, e, f, g, h, i [4]uint8
+}
+
+// neighboursAt gets the colors around a pixel, the edges of the frame are repeated past them
+func neighboursAt(img *image.RGBA, x, y int) neighbours {
+	at := func(dx, dy int) [4]uint8 {
+		c := pixelAt(img, x+dx, y+dy)
+		return [4]uint8{c.R, c.G, c.B, c.A}
+	}
+	return neighbours{
+		a: at(-1, -1), b: at(0, -1), c: at(1, -1),
+		d: at(-1, 0), e: at(0, 0), f: at(1, 0),
+		g: at(-1, 1), h: at(0, 1), i: at(1, 1),
+	}
+}
+
+// scale enlarges a frame by factor, block gives the colors of the factor x factor pixels replacing a pixel
+func scale(out *image.RGBA, f Frame, factor int, block func(n neighbours) [][4]uint8) *image.RGBA {
+	w, h := f.Image.Rect.Dx(), f.Image.Rect.Dy()
+	out = reuse(out, w*factor, h*factor)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			for i, c := range block(neighboursAt(f.Image, x, y)) {
+				o := out.PixOffset(x*factor+i%factor, y*factor+i/factor)
+				copy(out.Pix[o:o+4], c[:])
+			}
+		}
+	}
+	return out
+}
+
+// Scale2x enlarges the frame twice, rounding the diagonals of sprites instead of repeating pixels
+// See https://www.scale2x.it/algorithm
+type Scale2x struct {
+	out *image.RGBA
+}
+
+// Apply enlarges the frame twice
+func (s *Scale2x) Apply(f Frame) Frame {
+	s.out = scale(s.out, f, 2, func(n neighbours) [][4]uint8 {
+		e := [][4]uint8{n.e, n.e, n.e, n.e}
+		if n.b != n.h && n.d != n.f {
+			if n.d == n.b {
+				e[0] = n.d
+			}
+			if n.b == n.f {
+				e[1] = n.f
+			}
+			if n.d == n.h {
+				e[2] = n.d
+			}
+			if n.h == n.f {
+				e[3] = n.f
+			}
+		}
+		return e
+	})
+	return Frame{Image: s.out, Background: f.Background}
+}
+
+// Animating is always false, the scaled frame only depends on the frame
+func (s *Scale2x) Animating() bool {
+	return false
+}
+
+// Scale3x enlarges the frame 3 times, rounding the diagonals of sprites instead of repeating pixels
+// See https://www.scale2x.it/algorithm
+type Scale3x struct {
+	out *image.RGBA
+}
+
+// Apply enlarges the frame 3 times
+func (s *Scale3x) Apply(f Frame) Frame {
+	s.out = scale(s.out, f, 3, func(n neighbours) [][4]uint8 {
+		e := [][4]uint8{n.e, n.e, n.e, n.e, n.e, n.e, n.e, n.e, n.e}
+		if n.b != n.h && n.d != n.f {
+			if n.d == n.b {
+				e[0] = n.d
+			}
+			if (n.d == n.b && n.e != n.c) || (n.b == n.f && n.e != n.a) {
+				e[1] = n.b
+			}
+			if n.b == n.f {
+				e[2] = n.f
+			}
+			if (n.d == n.b && n.e != n.g) || (n.d == n.h && n.e != n.a) {
+				e[3] = n.d
+			}
+			if (n.b == n.f && n.e != n.i) || (n.h == n.f && n.e != n.c) {
+				e[5] = n.f
+			}
+			if n.d == n.h {
+				e[6] = n.d
+			}
+			if (n.d == n.h && n.e != n.i) || (n.h == n.f && n.e != n.g) {
+				e[7] = n.h
+			}
+			if n.h == n.f {
+				e[8] = n.f
+			}
+		}
+		return e
+	})
+	return Frame{Image: s.out, Background: f.Background}
+}
+
+// Animating is always false, the scaled frame only depends on the frame
+func (s *Scale3x) Animating() bool {
+	return false
+}
+
+// EPX enlarges the frame twice with Eric's Pixel Expansion, which leaves the pixels surrounded by 3 pixels of a
+// color square
+// See https://en.wikipedia.org/wiki/Pixel-art_scaling_algorithms#EPX/Scale2%C3%97/AdvMAME2%C3%97
+type EPX struct {
+	out *image.RGBA
+}
+
+// Apply enlarges the frame twice
+func (x *EPX) Apply(f Frame) Frame {
+	x.out = scale(x.out, f, 2, func(n neighbours) [][4]uint8 {
+		// The pixels above, right, left and below, as named by EPX
+		p, a, b, c, d := n.e, n.b, n.f, n.d, n.h
+		e := [][4]uint8{p, p, p, p}
+		same := 0
+		for _, pair := range [][2][4]uint8{{a, b}, {a, c}, {a, d}, {b, c}, {b, d}, {c, d}} {
+			if pair[0] == pair[1] {
+				same++
+			}
+		}
+		// 3 identical neighbours make 3 pairs, the pixel is then kept
+		if same >= 3 {
+			return e
+		}
+		if c == a {
+			e[0] = a
+		}
+		if a == b {
+			e[1] = b
+		}
+		if d == c {
+			e[2] = c
+		}
+		if b == d {
+			e[3] = d
+		}
+		return e
+	})
+	return Frame{Image: x.out, Background: f.Background}
+}
+
+// Animating is always false, the scaled frame only depends on the frame
+func (x *EPX) Animating() bool {
+	return false
+}
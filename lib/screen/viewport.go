@@ -0,0 +1,47 @@
+package screen
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// PixelAspectVIP is the width of the COSMAC VIP's pixels divided by their height, on a 4:3 TV
+// Its CDP1861 draws 64 pixels in 36µs of the 53µs of a visible line, and 128 of the 240 visible lines, so the
+// 64x32 display takes about 69% of the TV's width and 53% of its height.
+const PixelAspectVIP = 0.86
+
+// ParsePixelAspect parses a pixel aspect, the width of a pixel divided by its height: square, vip or a number
+func ParsePixelAspect(s string) (float64, error) {
+	switch strings.ToLower(s) {
+	case "", "square":
+		return 1, nil
+	case "vip":
+		return PixelAspectVIP, nil
+	}
+	aspect, err := strconv.ParseFloat(s, 64)
+	if err != nil || aspect <= 0 || math.IsInf(aspect, 0) {
+		return 0, fmt.Errorf("invalid pixel aspect %q, expected square, vip or a positive number", s)
+	}
+	return aspect, nil
+}
+
+// viewport gets where a display of w x h pixels of the given aspect is drawn in an output of outW x outH pixels:
+// as large as possible and centered, leaving black bars on the sides that do not fit
+// With integer, the pixels are a whole number of output pixels high, and as wide as their aspect makes them.
+func viewport(outW, outH, w, h int, aspect float64, integer bool) image.Rectangle {
+	pixelH := math.Min(float64(outW)/(float64(w)*aspect), float64(outH)/float64(h))
+	if integer {
+		pixelH = math.Max(1, math.Floor(pixelH))
+	}
+	pixelW := pixelH * aspect
+	if integer {
+		pixelW = math.Max(1, math.Round(pixelW))
+	}
+
+	vw, vh := int(math.Round(pixelW*float64(w))), int(math.Round(pixelH*float64(h)))
+	x, y := (outW-vw)/2, (outH-vh)/2
+	return image.Rect(x, y, x+vw, y+vh)
+}
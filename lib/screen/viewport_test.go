@@ -0,0 +1,37 @@
+package screen
+
+import (
+	"github.com/stretchr/testify/assert"
+	"image"
+	"testing"
+)
+
+func TestViewport(t *testing.T) {
+	// The display fills the window when their aspects match, and is centered between black bars otherwise
+	assert.Equal(t, image.Rect(0, 0, 1280, 640), viewport(1280, 640, 64, 32, 1, false))
+	assert.Equal(t, image.Rect(0, 180, 1280, 820), viewport(1280, 1000, 64, 32, 1, false))
+	assert.Equal(t, image.Rect(140, 0, 1140, 1000), viewport(1280, 1000, 64, 64, 1, false))
+	assert.Equal(t, image.Rect(0, 0, 1280, 640), viewport(1280, 640, 128, 64, 1, false))
+
+	// Integer scaling rounds the size of the pixels down
+	assert.Equal(t, image.Rect(10, 180, 1290, 820), viewport(1300, 1000, 64, 32, 1, true))
+	assert.Equal(t, image.Rect(2, 156, 98, 204), viewport(100, 360, 8, 4, 1, true))
+
+	// Pixels of the VIP are taller than wide
+	vp := viewport(1000, 1000, 64, 32, PixelAspectVIP, false)
+	assert.Equal(t, 1000, vp.Dx())
+	assert.Equal(t, 581, vp.Dy())
+}
+
+func TestParsePixelAspect(t *testing.T) {
+	aspect, err := ParsePixelAspect("vip")
+	assert.Nil(t, err)
+	assert.Equal(t, PixelAspectVIP, aspect)
+
+	aspect, err = ParsePixelAspect("1.5")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.5, aspect)
+
+	_, err = ParsePixelAspect("-1")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,44 @@
+package symbols
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Table maps ROM addresses to the names given by the ROM's author
+type Table map[uint16]string
+
+// Read reads a symbol file, made of "address name" lines with hexadecimal addresses
+// Empty lines and lines starting with # are skipped.
+func Read(r io.Reader) (Table, error) {
+	table := Table{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected an address and a name", line)
+		}
+		addr, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(fields[0]), "0x"), 16, 16)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		table[uint16(addr)] = fields[1]
+	}
+	return table, scanner.Err()
+}
+
+// Name gets the symbol at the given address, or the address itself prefixed by prefix
+func (t Table) Name(addr uint16, prefix string) string {
+	if name, ok := t[addr]; ok {
+		return name
+	}
+	return fmt.Sprintf("%s%03X", prefix, addr)
+}
@@ -0,0 +1,195 @@
+package timeline
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"io"
+)
+
+// frameDuration is the duration of a 60Hz frame, in microseconds
+const frameDuration = 1e6 / 60.0
+
+// Threads the events are displayed on
+const (
+	threadCPU = iota + 1
+	threadFrames
+	threadSound
+)
+
+// event is a Chrome trace event (https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU)
+type event struct {
+	Name  string                 `json:"name"`
+	Cat   string                 `json:"cat,omitempty"`
+	Ph    string                 `json:"ph"`
+	Ts    float64                `json:"ts"`
+	Dur   float64                `json:"dur,omitempty"`
+	Pid   int                    `json:"pid"`
+	Tid   int                    `json:"tid"`
+	Scope string                 `json:"s,omitempty"`
+	Args  map[string]interface{} `json:"args,omitempty"`
+}
+
+// Timeline records what the emulator does over time as Chrome trace events,
+// to be loaded in chrome://tracing or Perfetto
+// Time is the emulated time: frames last a 60th of a second and their cycles are spread evenly over them, so the
+// timeline does not depend on the speed of the host.
+type Timeline struct {
+	w          *bufio.Writer
+	err        error
+	events     int
+	tickrate   int
+	state      emulator.State
+	depth      int
+	frame      int
+	cycle      int
+	waiting    bool
+	waitStart  float64
+	soundStart float64
+	soundTimer uint8
+}
+
+// New creates a new Timeline writing its events to w, for an emulator emulating tickrate instructions per frame
+func New(w io.Writer, tickrate int) *Timeline {
+	t := &Timeline{
+		w:        bufio.NewWriter(w),
+		tickrate: tickrate,
+	}
+	t.write(event{Name: "process_name", Ph: "M", Args: map[string]interface{}{"name": "chip-go-8"}})
+	for tid, name := range []string{threadCPU: "CPU", threadFrames: "Frames", threadSound: "Sound"} {
+		if name == "" {
+			continue
+		}
+		t.write(event{Name: "thread_name", Ph: "M", Tid: tid, Args: map[string]interface{}{"name": name}})
+		t.write(event{Name: "thread_sort_index", Ph: "M", Tid: tid, Args: map[string]interface{}{"sort_index": tid}})
+	}
+	return t
+}
+
+// SetTickrate sets the number of instructions emulated per frame, from the next frame on
+func (t *Timeline) SetTickrate(tickrate int) {
+	t.tickrate = tickrate
+}
+
+// now gets the emulated time at the start of the current cycle, in microseconds since the timeline started
+func (t *Timeline) now() float64 {
+	return float64(t.frame)*frameDuration + float64(t.cycle)*frameDuration/float64(t.tickrate)
+}
+
+// write writes an event of the emulator's process, writing stops at the first error which is returned by Close
+func (t *Timeline) write(e event) {
+	if t.err != nil {
+		return
+	}
+	e.Pid = 1
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.err = err
+		return
+	}
+	separator := ",\n"
+	if t.events == 0 {
+		separator = "[\n"
+	}
+	t.events++
+	if _, t.err = t.w.WriteString(separator); t.err == nil {
+		_, t.err = t.w.Write(data)
+	}
+}
+
+// Frame marks the end of an emulated frame
+func (t *Timeline) Frame() {
+	t.write(event{Name: fmt.Sprintf("frame %d", t.frame), Cat: "frame", Ph: "X", Ts: float64(t.frame) * frameDuration, Dur: frameDuration, Tid: threadFrames})
+	t.frame++
+	t.cycle = 0
+}
+
+// Restart ends the calls and the key wait in progress, when the emulator starts its rom again or runs another one
+func (t *Timeline) Restart() {
+	t.endCalls(t.now())
+	t.waiting = false
+}
+
+// BeforeCycle snapshots the emulator before a cycle is emulated
+func (t *Timeline) BeforeCycle(c *emulator.Chip8) {
+	t.state = c.GetState()
+	// The timers tick between cycles
+	t.sound(t.now(), t.state.SoundTimer)
+}
+
+// AfterCycle records the events caused by the emulated instruction
+func (t *Timeline) AfterCycle(c *emulator.Chip8) {
+	cycleStart := t.now()
+	t.cycle++
+	now := t.now()
+	after := c.GetState()
+	opcode := t.state.Opcode
+
+	switch {
+	case opcode&0xF000 == 0x2000:
+		t.depth++
+		t.write(event{Name: fmt.Sprintf("sub_%03X", opcode&0x0FFF), Cat: "call", Ph: "B", Ts: cycleStart, Tid: threadCPU,
+			Args: map[string]interface{}{"from": fmt.Sprintf("0x%03X", t.state.PC)}})
+	case opcode == 0x00EE && t.depth > 0:
+		t.depth--
+		t.write(event{Ph: "E", Ts: now, Tid: threadCPU})
+	case opcode == 0x00E0:
+		t.write(event{Name: "clear", Cat: "draw", Ph: "i", Ts: cycleStart, Tid: threadCPU, Scope: "t"})
+	case opcode&0xF000 == 0xD000:
+		t.write(event{Name: "draw", Cat: "draw", Ph: "X", Ts: cycleStart, Dur: now - cycleStart, Tid: threadCPU,
+			Args: map[string]interface{}{
+				"x":         t.state.Registers[(opcode&0x0F00)>>8],
+				"y":         t.state.Registers[(opcode&0x00F0)>>4],
+				"height":    opcode & 0x000F,
+				"sprite":    fmt.Sprintf("0x%03X", t.state.I),
+				"collision": after.Registers[0xF] == 1,
+			}})
+	case opcode&0xF0FF == 0xF00A:
+		// The instruction is executed again until a key is pressed
+		if !t.waiting && after.PC == t.state.PC {
+			t.waiting = true
+			t.waitStart = cycleStart
+		} else if t.waiting && after.PC != t.state.PC {
+			t.waiting = false
+			t.write(event{Name: "key wait", Cat: "input", Ph: "X", Ts: t.waitStart, Dur: now - t.waitStart, Tid: threadCPU,
+				Args: map[string]interface{}{"key": after.Registers[(opcode&0x0F00)>>8]}})
+		}
+	}
+
+	t.sound(now, after.SoundTimer)
+}
+
+// sound records the changes of the sound timer
+func (t *Timeline) sound(now float64, value uint8) {
+	if value == t.soundTimer {
+		return
+	}
+	if t.soundTimer == 0 {
+		t.soundStart = now
+	} else if value == 0 {
+		t.write(event{Name: "sound", Cat: "sound", Ph: "X", Ts: t.soundStart, Dur: now - t.soundStart, Tid: threadSound})
+	}
+	t.soundTimer = value
+	t.write(event{Name: "sound timer", Cat: "sound", Ph: "C", Ts: now, Tid: threadSound,
+		Args: map[string]interface{}{"value": value}})
+}
+
+// endCalls ends the calls which have not returned
+func (t *Timeline) endCalls(now float64) {
+	for ; t.depth > 0; t.depth-- {
+		t.write(event{Ph: "E", Ts: now, Tid: threadCPU})
+	}
+}
+
+// Close ends the pending events and the JSON array, then flushes the timeline
+func (t *Timeline) Close() error {
+	t.endCalls(t.now())
+	if t.events > 0 && t.err == nil {
+		_, t.err = t.w.WriteString("\n]\n")
+	}
+	if t.err != nil {
+		return t.err
+	}
+	return t.w.Flush()
+}
@@ -0,0 +1,115 @@
+package timeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/mlemesle/chip-go-8/lib/beeper"
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTimeline(t *testing.T) {
+	c := emulator.New()
+	c.Initialize(beeper.NewMute())
+	assert.Nil(t, c.LoadBytes([]byte{
+		0x60, 0x02, // 200: LD V0, 0x02
+		0xF0, 0x18, // 202: LD ST, V0
+		0x22, 0x0A, // 204: CALL 0x20A
+		0x12, 0x06, // 206: JP 0x206
+		0x00, 0x00, // 208: padding
+		0x00, 0xE0, // 20A: CLS
+		0xD0, 0x05, // 20C: DRW V0, V0, 5
+		0x00, 0xEE, // 20E: RET
+	}))
+	var out bytes.Buffer
+	tl := New(&out, 3)
+	c.AddObserver(tl)
+	for frame := 0; frame < 3; frame++ {
+		assert.Nil(t, c.EmulateFrame(3))
+		tl.Frame()
+	}
+	assert.Nil(t, tl.Close())
+
+	var events []event
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &events))
+	byPhase := map[string][]event{}
+	for _, e := range events {
+		byPhase[e.Ph] = append(byPhase[e.Ph], e)
+	}
+
+	// The call and its return make a B/E pair on the CPU thread
+	assert.Equal(t, 1, len(byPhase["B"]))
+	assert.Equal(t, "sub_20A", byPhase["B"][0].Name)
+	assert.Equal(t, "0x204", byPhase["B"][0].Args["from"])
+	assert.Equal(t, 1, len(byPhase["E"]))
+	assert.Equal(t, threadCPU, byPhase["E"][0].Tid)
+
+	// Timestamps are emulated time: the call is the third of the 3 cycles of frame 0, the return ends frame 1
+	assert.InDelta(t, 2*frameDuration/3, byPhase["B"][0].Ts, 1e-6)
+	assert.InDelta(t, 2*frameDuration, byPhase["E"][0].Ts, 1e-6)
+
+	assert.Equal(t, 1, len(byPhase["i"]))
+	assert.Equal(t, "clear", byPhase["i"][0].Name)
+
+	var complete []string
+	for _, e := range byPhase["X"] {
+		complete = append(complete, e.Name)
+		if e.Cat == "frame" {
+			assert.Equal(t, frameDuration, e.Dur)
+		}
+		if e.Name == "draw" {
+			assert.Equal(t, "0x000", e.Args["sprite"])
+			assert.Equal(t, float64(2), e.Args["x"])
+			assert.Equal(t, float64(5), e.Args["height"])
+			assert.Equal(t, false, e.Args["collision"])
+		}
+	}
+	assert.ElementsMatch(t, []string{"draw", "sound", "frame 0", "frame 1", "frame 2"}, complete)
+
+	// The sound timer is counted down once per frame
+	var values []interface{}
+	for _, e := range byPhase["C"] {
+		assert.Equal(t, "sound timer", e.Name)
+		assert.Equal(t, threadSound, e.Tid)
+		values = append(values, e.Args["value"])
+	}
+	assert.Equal(t, []interface{}{float64(2), float64(1), float64(0)}, values)
+}
+
+func TestTimeline_Restart(t *testing.T) {
+	c := emulator.New()
+	c.Initialize(beeper.NewMute())
+	assert.Nil(t, c.LoadBytes([]byte{
+		0x22, 0x04, // 200: CALL 0x204
+		0x12, 0x02, // 202: JP 0x202
+		0x12, 0x04, // 204: JP 0x204
+	}))
+	var out bytes.Buffer
+	tl := New(&out, 2)
+	c.AddObserver(tl)
+	assert.Nil(t, c.EmulateFrame(2))
+	tl.Frame()
+
+	// The call never returns, restarting the rom ends it
+	assert.Nil(t, c.Reset())
+	tl.Restart()
+	tl.SetTickrate(4)
+	assert.Nil(t, c.EmulateFrame(4))
+	tl.Frame()
+	assert.Nil(t, tl.Close())
+
+	var events []event
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &events))
+	var calls, ends []float64
+	for _, e := range events {
+		switch e.Ph {
+		case "B":
+			calls = append(calls, e.Ts)
+		case "E":
+			ends = append(ends, e.Ts)
+		}
+	}
+	assert.Equal(t, []float64{0, frameDuration}, calls)
+	assert.Equal(t, []float64{frameDuration, 2 * frameDuration}, ends)
+}
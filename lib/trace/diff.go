@@ -0,0 +1,160 @@
+package trace
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Divergence is the first instruction where two aligned traces disagree
+type Divergence struct {
+	Ours   int
+	Theirs int
+	Fields []string
+}
+
+// Align finds the first pair of entries executing the same instruction
+// It looks for our first instruction in their trace, then for theirs in ours.
+func Align(ours, theirs []Entry) (int, int, error) {
+	if len(ours) == 0 || len(theirs) == 0 {
+		return 0, 0, errors.New("cannot align an empty trace")
+	}
+	for i := range theirs {
+		if sameInstruction(ours[0], theirs[i]) {
+			return 0, i, nil
+		}
+	}
+	for i := range ours {
+		if sameInstruction(ours[i], theirs[0]) {
+			return i, 0, nil
+		}
+	}
+	return 0, 0, errors.New("traces never execute the same instruction")
+}
+
+// sameInstruction tells if two entries are at the same address, with the same opcode when both have it
+func sameInstruction(a, b Entry) bool {
+	common := a.Fields & b.Fields
+	if common&FieldPC != 0 && a.PC != b.PC {
+		return false
+	}
+	if common&FieldOpcode != 0 && a.Opcode != b.Opcode {
+		return false
+	}
+	return common&(FieldPC|FieldOpcode) != 0
+}
+
+// Diff aligns the traces and steps through them in lockstep
+// It returns the first divergence, nil if none was found, and the number of compared instructions.
+func Diff(ours, theirs []Entry) (*Divergence, int, error) {
+	o, t, err := Align(ours, theirs)
+	if err != nil {
+		return nil, 0, err
+	}
+	compared := 0
+	for ; o < len(ours) && t < len(theirs); o, t = o+1, t+1 {
+		if fields := compare(ours[o], theirs[t]); len(fields) > 0 {
+			return &Divergence{Ours: o, Theirs: t, Fields: fields}, compared, nil
+		}
+		compared++
+	}
+	return nil, compared, nil
+}
+
+// compare lists the fields recorded by both entries whose values differ
+func compare(a, b Entry) []string {
+	var fields []string
+	common := a.Fields & b.Fields
+	if common&FieldPC != 0 && a.PC != b.PC {
+		fields = append(fields, "pc")
+	}
+	if common&FieldOpcode != 0 && a.Opcode != b.Opcode {
+		fields = append(fields, "op")
+	}
+	if common&FieldI != 0 && a.I != b.I {
+		fields = append(fields, "i")
+	}
+	if common&FieldSP != 0 && a.SP != b.SP {
+		fields = append(fields, "sp")
+	}
+	if common&FieldDelayTimer != 0 && a.DelayTimer != b.DelayTimer {
+		fields = append(fields, "dt")
+	}
+	if common&FieldSoundTimer != 0 && a.SoundTimer != b.SoundTimer {
+		fields = append(fields, "st")
+	}
+	for i := range a.Registers {
+		if common&fieldV(i) != 0 && a.Registers[i] != b.Registers[i] {
+			fields = append(fields, fmt.Sprintf("v%x", i))
+		}
+	}
+	if common&FieldMemory != 0 {
+		for _, addr := range diffWrites(a.Writes, b.Writes) {
+			fields = append(fields, fmt.Sprintf("mem[%03X]", addr))
+		}
+	}
+	return fields
+}
+
+// diffWrites lists the addresses written with different values, or by only one of the entries
+func diffWrites(a, b []Write) []uint16 {
+	values := make(map[uint16]int, len(a))
+	for _, w := range a {
+		values[w.Addr] = int(w.Value)
+	}
+	var addrs []uint16
+	for _, w := range b {
+		if v, ok := values[w.Addr]; !ok || v != int(w.Value) {
+			addrs = append(addrs, w.Addr)
+		}
+		delete(values, w.Addr)
+	}
+	for _, w := range a {
+		if _, ok := values[w.Addr]; ok {
+			addrs = append(addrs, w.Addr)
+		}
+	}
+	return addrs
+}
+
+// Report writes a human readable description of the divergence, with context entries around it
+func Report(w io.Writer, ours, theirs []Entry, d *Divergence, context int) {
+	fmt.Fprintf(w, "traces diverge at our line %d, their line %d: %v\n", ours[d.Ours].Line, theirs[d.Theirs].Line, d.Fields)
+	// Registers are recorded before execution, so a state mismatch comes from the previous instruction
+	if d.Ours > 0 && !contains(d.Fields, "pc") && !contains(d.Fields, "op") {
+		previous := ours[d.Ours-1]
+		fmt.Fprintf(w, "most likely caused by pc=%04X op=%04X\n", previous.PC, previous.Opcode)
+	}
+	fmt.Fprintln(w, "\nours:")
+	reportContext(w, ours, d.Ours, context)
+	fmt.Fprintln(w, "\ntheirs:")
+	reportContext(w, theirs, d.Theirs, context)
+}
+
+// reportContext writes the entries around the given index, marking it
+func reportContext(w io.Writer, entries []Entry, index, context int) {
+	from, to := index-context, index+context
+	if from < 0 {
+		from = 0
+	}
+	if to >= len(entries) {
+		to = len(entries) - 1
+	}
+	for i := from; i <= to; i++ {
+		marker := " "
+		if i == index {
+			marker = ">"
+		}
+		fmt.Fprintf(w, "%s %6d  %s\n", marker, entries[i].Line, entries[i])
+	}
+}
+
+// contains tells if s is one of the values
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
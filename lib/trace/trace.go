@@ -0,0 +1,307 @@
+package trace
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Field identifies a piece of the emulator's state recorded in a trace entry
+type Field uint32
+
+// Fields V0 to VF are the bits 0 to 15, one per register
+const (
+	FieldPC Field = 1 << (16 + iota)
+	FieldOpcode
+	FieldI
+	FieldSP
+	FieldDelayTimer
+	FieldSoundTimer
+	FieldMemory
+)
+
+// fieldRegisters is the set of the 16 register fields
+const fieldRegisters Field = 0xFFFF
+
+// fieldV gets the field of the register at the given index
+func fieldV(index int) Field {
+	return Field(1) << uint(index)
+}
+
+// Write is a byte written to memory by an instruction
+type Write struct {
+	Addr  uint16
+	Value uint8
+}
+
+// Entry is one executed instruction of a trace
+// Registers are the ones before the instruction is executed, Writes are the memory bytes it changed
+type Entry struct {
+	Line       int
+	PC         uint16
+	Opcode     uint16
+	I          uint16
+	SP         uint8
+	DelayTimer uint8
+	SoundTimer uint8
+	Registers  [16]uint8
+	Writes     []Write
+	Fields     Field
+}
+
+// Has tells if the entry recorded all the given fields
+func (e Entry) Has(f Field) bool {
+	return e.Fields&f == f
+}
+
+// String formats the entry in chip-go-8's own trace format, omitting the fields it does not have
+func (e Entry) String() string {
+	var sb strings.Builder
+	if e.Has(FieldPC) {
+		fmt.Fprintf(&sb, " pc=%04X", e.PC)
+	}
+	if e.Has(FieldOpcode) {
+		fmt.Fprintf(&sb, " op=%04X", e.Opcode)
+	}
+	if e.Has(FieldI) {
+		fmt.Fprintf(&sb, " i=%04X", e.I)
+	}
+	if e.Has(FieldSP) {
+		fmt.Fprintf(&sb, " sp=%X", e.SP)
+	}
+	if e.Has(FieldDelayTimer) {
+		fmt.Fprintf(&sb, " dt=%02X", e.DelayTimer)
+	}
+	if e.Has(FieldSoundTimer) {
+		fmt.Fprintf(&sb, " st=%02X", e.SoundTimer)
+	}
+	if e.Has(fieldRegisters) {
+		fmt.Fprintf(&sb, " v=%X", e.Registers[:])
+	} else {
+		for i, v := range e.Registers {
+			if e.Has(fieldV(i)) {
+				fmt.Fprintf(&sb, " v%x=%02X", i, v)
+			}
+		}
+	}
+	if e.Has(FieldMemory) && len(e.Writes) > 0 {
+		writes := make([]string, len(e.Writes))
+		for i, w := range e.Writes {
+			writes[i] = fmt.Sprintf("%03X:%02X", w.Addr, w.Value)
+		}
+		fmt.Fprintf(&sb, " w=%s", strings.Join(writes, ","))
+	}
+	return strings.TrimPrefix(sb.String(), " ")
+}
+
+// Format describes the columns of a trace exported by another emulator
+type Format struct {
+	Columns   []string
+	Separator string
+}
+
+// ParseFormat parses a comma separated list of column names
+//
+// Known names are pc, op, i, sp, dt, st, v (the 16 registers as one hex string),
+// v0 to vf, the range v0-vf and w (memory writes as addr:value pairs). Columns named _ are ignored.
+// An empty separator means columns are separated by whitespaces.
+func ParseFormat(spec, separator string) (*Format, error) {
+	f := &Format{Separator: separator}
+	for _, column := range strings.Split(strings.ToLower(spec), ",") {
+		column = strings.TrimSpace(column)
+		if column == "v0-vf" {
+			for i := 0; i < 16; i++ {
+				f.Columns = append(f.Columns, fmt.Sprintf("v%x", i))
+			}
+			continue
+		}
+		if _, ok := columnField(column); !ok && column != "_" {
+			return nil, fmt.Errorf("unknown trace column %q", column)
+		}
+		f.Columns = append(f.Columns, column)
+	}
+	return f, nil
+}
+
+// columnField gets the field recorded by a column name
+func columnField(column string) (Field, bool) {
+	switch column {
+	case "pc":
+		return FieldPC, true
+	case "op":
+		return FieldOpcode, true
+	case "i":
+		return FieldI, true
+	case "sp":
+		return FieldSP, true
+	case "dt":
+		return FieldDelayTimer, true
+	case "st":
+		return FieldSoundTimer, true
+	case "v":
+		return fieldRegisters, true
+	case "w":
+		return FieldMemory, true
+	}
+	if len(column) == 2 && column[0] == 'v' {
+		if index, err := strconv.ParseUint(column[1:], 16, 8); err == nil {
+			return fieldV(int(index)), true
+		}
+	}
+	return 0, false
+}
+
+// Read reads all the entries of a trace
+// A nil format reads chip-go-8's own format. Empty lines and lines starting with # are skipped.
+func Read(r io.Reader, f *Format) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var e Entry
+		var err error
+		if f == nil {
+			e, err = parseOwn(text)
+		} else {
+			e, err = f.parse(text)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		e.Line = line
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// parseOwn parses a line of chip-go-8's own format: a step number followed by name=value pairs
+// Memory writes are always recorded by this format, a line without any means none happened.
+func parseOwn(text string) (Entry, error) {
+	e := Entry{Fields: FieldMemory}
+	for i, token := range strings.Fields(text) {
+		separator := strings.IndexByte(token, '=')
+		if separator < 0 {
+			if i == 0 {
+				continue
+			}
+			return e, fmt.Errorf("malformed token %q", token)
+		}
+		if err := e.set(token[:separator], token[separator+1:]); err != nil {
+			return e, err
+		}
+	}
+	return e, nil
+}
+
+// parse parses a line of a foreign trace
+func (f *Format) parse(text string) (Entry, error) {
+	var e Entry
+	var values []string
+	if f.Separator == "" {
+		values = strings.Fields(text)
+	} else {
+		values = strings.Split(text, f.Separator)
+	}
+	if len(values) < len(f.Columns) {
+		return e, fmt.Errorf("expected %d columns, found %d", len(f.Columns), len(values))
+	}
+	for i, column := range f.Columns {
+		if column == "_" {
+			continue
+		}
+		value := strings.TrimSpace(values[i])
+		// Accept labelled values such as "PC:0200" or "V3=1F"
+		if separator := strings.LastIndexAny(value, ":="); separator >= 0 && column != "w" {
+			value = value[separator+1:]
+		}
+		if err := e.set(column, value); err != nil {
+			return e, err
+		}
+	}
+	return e, nil
+}
+
+// set parses the hexadecimal value of the named column into the entry
+func (e *Entry) set(column, value string) error {
+	field, ok := columnField(strings.ToLower(column))
+	if !ok {
+		return fmt.Errorf("unknown field %q", column)
+	}
+	value = strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(value, "0x"), "0X"), "$")
+
+	switch field {
+	case fieldRegisters:
+		if len(value) != 32 {
+			return fmt.Errorf("expected 16 registers in %q", value)
+		}
+		for i := range e.Registers {
+			v, err := strconv.ParseUint(value[i*2:i*2+2], 16, 8)
+			if err != nil {
+				return err
+			}
+			e.Registers[i] = uint8(v)
+		}
+	case FieldMemory:
+		writes, err := parseWrites(value)
+		if err != nil {
+			return err
+		}
+		e.Writes = writes
+	default:
+		v, err := strconv.ParseUint(value, 16, 16)
+		if err != nil {
+			return fmt.Errorf("field %s: %v", column, err)
+		}
+		switch field {
+		case FieldPC:
+			e.PC = uint16(v)
+		case FieldOpcode:
+			e.Opcode = uint16(v)
+		case FieldI:
+			e.I = uint16(v)
+		case FieldSP:
+			e.SP = uint8(v)
+		case FieldDelayTimer:
+			e.DelayTimer = uint8(v)
+		case FieldSoundTimer:
+			e.SoundTimer = uint8(v)
+		default:
+			for i := range e.Registers {
+				if field == fieldV(i) {
+					e.Registers[i] = uint8(v)
+				}
+			}
+		}
+	}
+	e.Fields |= field
+	return nil
+}
+
+// parseWrites parses a list of memory writes such as "300:01,301:FF"
+func parseWrites(value string) ([]Write, error) {
+	var writes []Write
+	for _, w := range strings.Split(value, ",") {
+		separator := strings.IndexByte(w, ':')
+		if separator < 0 {
+			return nil, errors.New("malformed memory write " + w)
+		}
+		addr, err := strconv.ParseUint(w[:separator], 16, 16)
+		if err != nil {
+			return nil, err
+		}
+		v, err := strconv.ParseUint(w[separator+1:], 16, 8)
+		if err != nil {
+			return nil, err
+		}
+		writes = append(writes, Write{Addr: uint16(addr), Value: uint8(v)})
+	}
+	return writes, nil
+}
@@ -0,0 +1,100 @@
+package trace
+
+import (
+	"bytes"
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+const ourTrace = `# chip-go-8 trace
+0 pc=0200 op=6A02 i=0000 sp=0 dt=00 st=00 v=00000000000000000000000000000000
+1 pc=0202 op=A300 i=0000 sp=0 dt=00 st=00 v=00000000000000000000020000000000
+2 pc=0204 op=FA55 i=0300 sp=0 dt=00 st=00 v=00000000000000000000020000000000 w=30A:02
+3 pc=0206 op=8AB4 i=0300 sp=0 dt=00 st=00 v=00000000000000000000020000000000
+`
+
+func TestRead_ownFormat(t *testing.T) {
+	entries, err := Read(strings.NewReader(ourTrace), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(entries))
+	assert.Equal(t, uint16(0x202), entries[1].PC)
+	assert.Equal(t, uint16(0xA300), entries[1].Opcode)
+	assert.Equal(t, uint8(0x02), entries[1].Registers[0xA])
+	assert.Equal(t, []Write{{Addr: 0x30A, Value: 0x02}}, entries[2].Writes)
+	assert.Equal(t, 3, entries[1].Line)
+	assert.Equal(t, "pc=0204 op=FA55 i=0300 sp=0 dt=00 st=00 v=00000000000000000000020000000000 w=30A:02", entries[2].String())
+}
+
+func TestWriter(t *testing.T) {
+	c := emulator.New()
+	c.Initialize(nil)
+	// FA55 writes V0 to VA, only VA changes the memory, then FA33 changes 2 of the 3 bytes it writes
+	assert.Nil(t, c.LoadBytes([]byte{0x6A, 0x02, 0xA3, 0x00, 0xFA, 0x55, 0x8A, 0xB4, 0x6A, 0x0C, 0xFA, 0x33}))
+	var out bytes.Buffer
+	tw := NewWriter(&out)
+	c.AddObserver(tw)
+	for i := 0; i < 6; i++ {
+		assert.Nil(t, c.EmulateCycle())
+	}
+	assert.Nil(t, tw.Flush())
+
+	entries, err := Read(&out, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 6, len(entries))
+	assert.Equal(t, []Write{{Addr: 0x30A, Value: 0x02}}, entries[2].Writes)
+	assert.Empty(t, entries[3].Writes)
+	assert.Equal(t, []Write{{Addr: 0x301, Value: 0x01}, {Addr: 0x302, Value: 0x02}}, entries[5].Writes)
+}
+
+func TestRead_columnFormat(t *testing.T) {
+	format, err := ParseFormat("_,pc,op,i,va,vf", "|")
+	assert.Nil(t, err)
+
+	entries, err := Read(strings.NewReader("1|PC:0x0202|A300|I=0000|02|00\n"), format)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, uint16(0x202), entries[0].PC)
+	assert.Equal(t, uint8(0x02), entries[0].Registers[0xA])
+	assert.True(t, entries[0].Has(FieldPC|FieldI|fieldV(0xA)|fieldV(0xF)))
+	assert.False(t, entries[0].Has(fieldV(0x0)))
+}
+
+func TestParseFormat_unknownColumn(t *testing.T) {
+	_, err := ParseFormat("pc,flags", "")
+	assert.NotNil(t, err)
+}
+
+func TestDiff_registerDivergence(t *testing.T) {
+	ours, _ := Read(strings.NewReader(ourTrace), nil)
+	format, _ := ParseFormat("pc,op,va,vf", "")
+	theirs, _ := Read(strings.NewReader(`0000 00E0 00 00
+0200 6A02 00 00
+0202 A300 03 00
+0204 FA55 03 00
+`), format)
+
+	divergence, compared, err := Diff(ours, theirs)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, compared)
+	assert.Equal(t, &Divergence{Ours: 1, Theirs: 2, Fields: []string{"va"}}, divergence)
+}
+
+func TestDiff_memoryDivergence(t *testing.T) {
+	ours, _ := Read(strings.NewReader(ourTrace), nil)
+	theirs, _ := Read(strings.NewReader(strings.Replace(ourTrace, "w=30A:02", "w=30A:03,30B:00", 1)), nil)
+
+	divergence, _, err := Diff(ours, theirs)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mem[30A]", "mem[30B]"}, divergence.Fields)
+}
+
+func TestDiff_agree(t *testing.T) {
+	ours, _ := Read(strings.NewReader(ourTrace), nil)
+
+	divergence, compared, err := Diff(ours, ours[2:])
+	assert.Nil(t, err)
+	assert.Nil(t, divergence)
+	assert.Equal(t, 2, compared)
+}
@@ -0,0 +1,81 @@
+package trace
+
+import (
+	"bufio"
+	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// Writer records the emulator's execution in chip-go-8's own trace format
+type Writer struct {
+	w     *bufio.Writer
+	err   error
+	step  int
+	state emulator.State
+}
+
+// NewWriter creates a new Writer, writing the trace to w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// BeforeCycle snapshots the registers of the emulator before a cycle is emulated
+func (tw *Writer) BeforeCycle(c *emulator.Chip8) {
+	tw.state = c.GetState()
+}
+
+// AfterCycle writes the trace entry of the cycle emulated since BeforeCycle
+// Writing stops at the first error, which is returned by Flush.
+func (tw *Writer) AfterCycle(c *emulator.Chip8) {
+	if tw.err != nil {
+		return
+	}
+	e := Entry{
+		PC:         tw.state.PC,
+		Opcode:     tw.state.Opcode,
+		I:          tw.state.I,
+		SP:         tw.state.SP,
+		DelayTimer: tw.state.DelayTimer,
+		SoundTimer: tw.state.SoundTimer,
+		Registers:  tw.state.Registers,
+		Writes:     changedBytes(c.MemoryWrites()),
+		Fields:     FieldPC | FieldOpcode | FieldI | FieldSP | FieldDelayTimer | FieldSoundTimer | fieldRegisters | FieldMemory,
+	}
+
+	_, tw.err = io.WriteString(tw.w, strconv.Itoa(tw.step)+" "+e.String()+"\n")
+	tw.step++
+}
+
+// Flush writes any buffered entry to the underlying writer
+func (tw *Writer) Flush() error {
+	if tw.err != nil {
+		return tw.err
+	}
+	return tw.w.Flush()
+}
+
+// changedBytes gets the bytes a cycle changed from its writes, sorted by address
+// Only the last write to an address counts, and bytes written with the value they held are left out.
+func changedBytes(writes []emulator.MemoryWrite) []Write {
+	if len(writes) == 0 {
+		return nil
+	}
+	var changed []Write
+	old := map[uint16]uint8{}
+	last := map[uint16]uint8{}
+	for _, w := range writes {
+		if _, ok := old[w.Addr]; !ok {
+			old[w.Addr] = w.Old
+		}
+		last[w.Addr] = w.Value
+	}
+	for addr, value := range last {
+		if value != old[addr] {
+			changed = append(changed, Write{Addr: addr, Value: value})
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i].Addr < changed[j].Addr })
+	return changed
+}
@@ -2,28 +2,99 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"github.com/mlemesle/chip-go-8/lib/beeper"
+	"github.com/mlemesle/chip-go-8/lib/coverage"
 	"github.com/mlemesle/chip-go-8/lib/emulator"
+	"github.com/mlemesle/chip-go-8/lib/romdb"
 	"github.com/mlemesle/chip-go-8/lib/screen"
+	"github.com/mlemesle/chip-go-8/lib/timeline"
+	"github.com/mlemesle/chip-go-8/lib/trace"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
+// commands are the tools run instead of the emulator when named as first argument
+var commands = map[string]func(args []string) error{
+	"cfg":       runCFG,
+	"coverage":  runCoverage,
+	"mkpatch":   runMkpatch,
+	"profile":   runProfile,
+	"tracediff": runTraceDiff,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := command(os.Args[2:]); err != nil {
+				panic(err)
+			}
+			return
+		}
+	}
+
 	ratio := flag.Int("ratio", 20, "The ratio of the screen. The screen standard size is 64x32.")
 	isMuted := flag.Bool("mute", false, "The emulator will be muted if set.")
 	runTest := flag.Bool("test", false, "If set, the emulator will boot with the test chip8 image from https://github.com/corax89/chip8-test-rom")
-	romFile := flag.String("rom", "rom/pong.c8", "Specify a rom file to run. If not set, a pong image will be loaded")
+	romFile := flag.String("rom", "rom/pong.c8", "Specify a rom file to run, - to read it from the standard input or archive.zip:path/to/rom.ch8 for a rom in a zip archive. Intel HEX files and hex listings are decoded. If not set, a pong image will be loaded")
+	traceFile := flag.String("trace", "", "Record every executed instruction in the given file, to be compared with tracediff")
+	timelineFile := flag.String("timeline", "", "Record frames, subroutine calls, draws, key waits and sound in the given file, in Chrome's trace event format")
+	coverageFile := flag.String("coverage", "", "Write which instructions and data of the rom were used to the given file when quitting, as html if it ends with .html")
+	ipf := flag.Int("ipf", 0, "Number of instructions emulated per frame. If not set, the rom database's recommendation is used")
+	romdbFile := flag.String("romdb", "", "A programs.json file in the chip-8-database format, overriding the embedded rom database")
+	hybrid := flag.Bool("hybrid", false, "Run the RCA 1802 machine-code subroutines called with 0NNN, for hybrid COSMAC VIP programs")
+	platform := addPlatformFlag(flag.CommandLine)
+	font := flag.String("font", "", "Font drawn by the roms: "+strings.Join(emulator.FontIDs(), ", ")+
+		", or a font file of 80 bytes, optionally followed by a large font of 100 or 160 bytes. If not set, the platform's font is used")
+	frontend := flag.String("frontend", "sdl", "Where to display the emulator: sdl for a window, or terminal for a terminal supporting truecolor, muted")
+	palette := flag.String("palette", "", "Colors of the display: "+strings.Join(screen.PaletteIDs(), ", ")+
+		". If not set, the rom database's colors are used, or classic. P cycles through the palettes")
+	persistence := flag.Float64("persistence", 0, "Phosphor persistence against flicker, the part of its color a pixel that turns off keeps at each frame, between 0 and 1")
+	blend := flag.Int("blend", 0, "Number of frames blended together against flicker")
+	filter := flag.String("filter", "", "Comma-separated filters applied to the display, in order: "+strings.Join(screen.FilterIDs(), ", "))
+	fullscreen := flag.Bool("fullscreen", false, "Start in fullscreen, F11 switches between the window and fullscreen")
+	integer := flag.Bool("integer", false, "Scale the display by whole numbers only, leaving larger black bars around it")
+	aspect := flag.String("aspect", "square", "Aspect of the pixels: square, vip for the COSMAC VIP's pixels on a TV, or their width divided by their height")
+	spriteDebug := flag.Bool("sprites", false, "Start with the debug overlay, which outlines the sprites drawn and their collisions and describes the sprite under the mouse, F5 shows or hides it")
+	romDir := flag.String("romdir", "", "Directory the rom browser of the menu opens in, Escape opens the menu. If not set, the directory of the rom is used")
+	var patches listFlag
+	flag.Var(&patches, "patch", "Apply an IPS or BPS patch to the rom before loading it. Can be repeated, the patches are applied in order")
 	flag.Parse()
 
-	chip8ScreenSDL := screen.NewChip8ScreenSDL(64, 32, int32(*ratio))
-	err := chip8ScreenSDL.Init()
+	var chip8Screen screen.Chip8ScreenInterface
+	switch *frontend {
+	case "sdl":
+		pixelAspect, err := screen.ParsePixelAspect(*aspect)
+		if err != nil {
+			panic(err)
+		}
+		sdlScreen := screen.NewChip8ScreenSDL(64, 32, int32(*ratio))
+		sdlScreen.SetScaling(*integer, pixelAspect)
+		sdlScreen.SetSpriteDebug(*spriteDebug)
+		if err = sdlScreen.SetFullscreen(*fullscreen); err != nil {
+			panic(err)
+		}
+		chip8Screen = sdlScreen
+	case "terminal":
+		// The terminal frontend reads the keys from the standard input, which the rom can't be read from too
+		if *romFile == "-" {
+			panic(fmt.Errorf("-rom - can't be used with -frontend terminal, which reads the keyboard from the standard input"))
+		}
+		chip8Screen = screen.NewTerminal()
+	default:
+		panic(fmt.Errorf("unknown frontend %q, expected sdl or terminal", *frontend))
+	}
+	err := chip8Screen.Init()
 	if err != nil {
 		panic(err)
 	}
-	defer chip8ScreenSDL.Destroy()
+	defer chip8Screen.Destroy()
 
 	var chip8Beeper beeper.BeeperInterface
-	if *isMuted {
+	// The SDL beeper needs SDL, which the terminal frontend does without
+	if *isMuted || *frontend != "sdl" {
 		chip8Beeper = beeper.NewMute()
 	} else {
 		chip8Beeper = beeper.NewSDL()
@@ -39,25 +110,167 @@ func main() {
 	if *runTest {
 		*romFile = "rom/test_opcode.ch8"
 	}
-	err = chip8.LoadMemory(*romFile)
+
+	db := romdb.Embedded()
+	if *romdbFile != "" {
+		file, err := os.Open(*romdbFile)
+		if err != nil {
+			panic(err)
+		}
+		err = db.Merge(file)
+		file.Close()
+		if err != nil {
+			panic(err)
+		}
+	}
+	var romPalette *screen.Palette
+	if *palette != "" {
+		p, ok := screen.Palettes[*palette]
+		if !ok {
+			panic(fmt.Errorf("unknown palette %q, known palettes are %s", *palette, strings.Join(screen.PaletteIDs(), ", ")))
+		}
+		romPalette = &p
+	}
+	settings := romSettings{db: db, platform: *platform, font: *font, hybrid: *hybrid, ipf: *ipf, palette: romPalette}
+	match, found, err := settings.load(chip8, *romFile, patches)
 	if err != nil {
 		panic(err)
 	}
+	romIPF := settings.apply(chip8Screen, match, found)
+
+	if *persistence < 0 || *persistence >= 1 {
+		panic(fmt.Errorf("-persistence must be between 0 and 1, got %v", *persistence))
+	}
+	if *persistence > 0 {
+		chip8Screen.AddFilter(screen.NewPersistence(*persistence))
+	}
+	if *blend > 1 {
+		chip8Screen.AddFilter(screen.NewBlend(*blend))
+	}
+	filters, err := screen.ParseFilters(*filter)
+	if err != nil {
+		panic(err)
+	}
+	for _, f := range filters {
+		chip8Screen.AddFilter(f)
+	}
+	if sdlScreen, ok := chip8Screen.(*screen.Chip8ScreenSDL); ok {
+		if *romDir == "" {
+			*romDir = filepath.Dir(strings.SplitN(*romFile, ":", 2)[0])
+		}
+		sdlScreen.SetMenu(screen.MenuOptions{ROMDir: *romDir, Describe: settings.describe, Profiles: settings.profiles()})
+	}
 
-	for {
-		if err = chip8.EmulateCycle(); err != nil {
+	if *traceFile != "" {
+		file, err := os.Create(*traceFile)
+		if err != nil {
 			panic(err)
 		}
+		defer file.Close()
+		tracer := trace.NewWriter(file)
+		chip8.AddObserver(tracer)
+		defer func() {
+			if err := tracer.Flush(); err != nil {
+				panic(err)
+			}
+		}()
+	}
 
-		if chip8.NeedDraw() {
-			if err = chip8ScreenSDL.Draw(chip8); err != nil {
+	if *coverageFile != "" {
+		cov := coverage.New()
+		chip8.AddObserver(cov)
+		defer func() {
+			if err := writeCoverage(*coverageFile, cov, chip8, nil); err != nil {
 				panic(err)
 			}
+		}()
+	}
+
+	var chip8Timeline *timeline.Timeline
+	if *timelineFile != "" {
+		file, err := os.Create(*timelineFile)
+		if err != nil {
+			panic(err)
 		}
+		defer file.Close()
+		chip8Timeline = timeline.New(file, romIPF)
+		chip8.AddObserver(chip8Timeline)
+		defer func() {
+			if err := chip8Timeline.Close(); err != nil {
+				panic(err)
+			}
+		}()
+	}
+
+	controls := screen.NewControls()
+	osd := screen.NewOSD()
+	chip8Screen.SetControls(controls)
+	chip8Screen.SetOSD(osd)
+	counter := &cycleCounter{}
+	chip8.AddObserver(counter)
 
-		quitEvent := chip8ScreenSDL.HandleEvent(chip8)
+	ticker := time.NewTicker(time.Second / 60)
+	defer ticker.Stop()
+	// frames is how many frames are due at the current speed, a speed of 0.5 emulates a frame every other tick
+	frames := 0.0
+	statsFrames, statsCycles, statsSince := 0, counter.cycles, time.Now()
+	for range ticker.C {
+		if !controls.Paused {
+			frames += controls.Speed
+		}
+		for ; frames >= 1; frames-- {
+			if err = chip8.EmulateFrame(romIPF); err != nil {
+				panic(err)
+			}
+			if chip8Timeline != nil {
+				chip8Timeline.Frame()
+			}
+			statsFrames++
+		}
+		if elapsed := time.Since(statsSince); elapsed >= time.Second {
+			osd.SetStats(screen.Stats{
+				FPS: float64(statsFrames) / elapsed.Seconds(),
+				IPS: float64(counter.cycles-statsCycles) / elapsed.Seconds(),
+			})
+			statsFrames, statsCycles, statsSince = 0, counter.cycles, time.Now()
+		}
+
+		// Filters fading or blending frames keep changing the display after the emulator drew
+		if chip8.NeedDraw() || chip8Screen.Animating() {
+			if err = chip8Screen.Draw(chip8); err != nil {
+				panic(err)
+			}
+		}
+
+		quitEvent := chip8Screen.HandleEvent(chip8)
 		if quitEvent {
-			os.Exit(0)
+			return
+		}
+
+		// The menu and dropped files ask to reset the rom or to run another one, the window and the audio are kept
+		if controls.Reset || controls.Load != "" {
+			var restarted bool
+			if romIPF, restarted, err = settings.runRequests(chip8, chip8Screen, controls, osd, romIPF); err != nil {
+				panic(err)
+			}
+			if restarted && chip8Timeline != nil {
+				chip8Timeline.Restart()
+				chip8Timeline.SetTickrate(romIPF)
+			}
+			frames = 0
 		}
 	}
 }
+
+// cycleCounter counts the cycles emulated, for the stats of the OSD
+type cycleCounter struct {
+	cycles int
+}
+
+// BeforeCycle does nothing, cycles are counted once emulated
+func (c *cycleCounter) BeforeCycle(chip8 *emulator.Chip8) {}
+
+// AfterCycle counts a cycle
+func (c *cycleCounter) AfterCycle(chip8 *emulator.Chip8) {
+	c.cycles++
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/mlemesle/chip-go-8/lib/patch"
+	"github.com/mlemesle/chip-go-8/lib/romfile"
+	"io/ioutil"
+	"os"
+)
+
+// runMkpatch writes the IPS or BPS patch turning a rom into another, to share changes without the roms
+func runMkpatch(args []string) error {
+	flags := flag.NewFlagSet("mkpatch", flag.ExitOnError)
+	format := flags.String("format", "", "Patch format, ips or bps. If not set, the extension of the patch file is used")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s mkpatch [flags] original.ch8 modified.ch8 fix.bps\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	positional := parseArgs(flags, args)
+	if len(positional) != 3 {
+		flags.Usage()
+		return errors.New("mkpatch needs the original rom, the modified rom and the patch file")
+	}
+	if *format == "" {
+		*format = patch.FormatOf(positional[2])
+	}
+
+	source, _, err := romfile.Load(positional[0])
+	if err != nil {
+		return err
+	}
+	target, _, err := romfile.Load(positional[1])
+	if err != nil {
+		return err
+	}
+	data, err := patch.Create(*format, source, target)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(positional[2], data, 0644)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/mlemesle/chip-go-8/lib/profiler"
+	"os"
+)
+
+// runProfile runs a rom without display and writes where it spent its instructions as a pprof profile
+func runProfile(args []string) error {
+	flags := flag.NewFlagSet("profile", flag.ExitOnError)
+	frames := flags.Int("frames", 6000, "Number of frames to emulate")
+	ipf := flags.Int("ipf", 0, "Number of instructions emulated per frame. If not set, the rom database's recommendation is used")
+	output := flags.String("o", "chip-go-8.pprof", "File to write the pprof profile to")
+	symbolFile := flags.String("symbols", "", "File of \"address name\" lines naming the rom's subroutines")
+	platform := addPlatformFlag(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s profile [flags] rom.ch8\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	positional := parseArgs(flags, args)
+	if len(positional) != 1 {
+		flags.Usage()
+		return errors.New("profile needs a rom file")
+	}
+
+	table, err := readSymbols(*symbolFile)
+	if err != nil {
+		return err
+	}
+	chip8, tickrate, err := newHeadless(positional[0], *platform, *ipf)
+	if err != nil {
+		return err
+	}
+
+	p := profiler.New(table)
+	chip8.AddObserver(p)
+	for frame := 0; frame < *frames; frame++ {
+		if err = chip8.EmulateFrame(tickrate); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return p.Write(file)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/mlemesle/chip-go-8/lib/trace"
+	"os"
+)
+
+// runTraceDiff compares a trace recorded with -trace against another emulator's trace
+func runTraceDiff(args []string) error {
+	flags := flag.NewFlagSet("tracediff", flag.ExitOnError)
+	format := flags.String("format", "", "Columns of their trace, such as \"pc,op,i,v0-vf\". If not set, their trace uses chip-go-8's format")
+	separator := flags.String("sep", "", "Column separator of their trace. If not set, columns are separated by whitespaces")
+	context := flags.Int("context", 5, "Number of instructions shown around the divergence")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s tracediff [flags] ours.log theirs.log\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	positional := parseArgs(flags, args)
+	if len(positional) != 2 {
+		flags.Usage()
+		return errors.New("tracediff needs two trace files")
+	}
+
+	var theirFormat *trace.Format
+	if *format != "" {
+		var err error
+		if theirFormat, err = trace.ParseFormat(*format, *separator); err != nil {
+			return err
+		}
+	}
+
+	ours, err := readTrace(positional[0], nil)
+	if err != nil {
+		return err
+	}
+	theirs, err := readTrace(positional[1], theirFormat)
+	if err != nil {
+		return err
+	}
+
+	divergence, compared, err := trace.Diff(ours, theirs)
+	if err != nil {
+		return err
+	}
+	if divergence == nil {
+		fmt.Printf("traces agree on %d instructions\n", compared)
+		return nil
+	}
+	trace.Report(os.Stdout, ours, theirs, divergence, *context)
+	os.Exit(1)
+	return nil
+}
+
+// readTrace reads the trace file with the given format
+func readTrace(filename string, format *trace.Format) ([]trace.Entry, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries, err := trace.Read(file, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return entries, nil
+}